@@ -0,0 +1,662 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the implementation of the ZKRP scheme proposed in the paper:
+Bulletproofs: Short Proofs for Confidential Transactions and More
+Benedikt Bunz, Jonathan Bootle, Dan Boneh, Andrew Poelstra, Pieter Wuille, Greg Maxwell
+IEEE S&P 2018
+
+Unlike the ccs08 scheme, Bulletproofs require no trusted setup: the only
+public parameters are a handful of independent generators of bn256.G1, so
+there is no signing key that needs to be protected.
+
+AggregateProve implements the paper's multi-value aggregation directly
+(section 4.3): m values are proved in one go by splitting each into two
+n-bit slots, concatenating the 2*m slot decompositions into a single
+length-2*m*n statement and offsetting each slot's range constraint by a
+distinct power z^{2+slot} of the challenge z, so the proof size grows with
+log2(2*m*n) rather than with m.
+*/
+package bulletproof
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256"
+	"github.com/ing-bank/zkrangeproof/go-ethereum/zkrangeproof"
+)
+
+// fieldP is the alt_bn128/BN254 base field modulus (distinct from bn256.Order,
+// the prime order of the G1/G2 subgroups): the field x,y coordinates live in,
+// per EIP-196/197. curveB is the G1 curve's constant in y^2 = x^3 + curveB.
+var (
+	fieldP, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+	curveB    = big.NewInt(3)
+)
+
+// Independent generators. G and H are the Pedersen bases used for value and
+// blinding factors; U is a third generator used only as the cross-term base
+// inside the inner product argument, kept distinct from G so the two
+// commitments never bind to each other.
+var (
+	G = new(bn256.G1).ScalarBaseMult(new(big.Int).SetInt64(1))
+	H = hashToG1([]byte("bulletproof/H"))
+	U = hashToG1([]byte("bulletproof/U"))
+)
+
+// Params holds the public parameters of the range proof: the range [a,b)
+// and n, the number of bits required to represent a single value's width,
+// i.e. n = ceil(log2(b-a+1)). No secret material is involved, so Setup can
+// be run by anyone and produces the same generators as anyone else.
+type Params struct {
+	a, b int64
+	n    int64
+}
+
+/*
+Setup configures the public parameters of the scheme for the range [a, b).
+
+A single bit-decomposition of v-a into [0, 2^n) only bounds v from below:
+when w = b-a+1 isn't itself a power of two, 2^n > w leaves slack a prover
+could use to open the proof for a value above b. Prove/Verify close that
+gap with a two-sided decomposition: they additionally constrain b-1-v into
+[0, 2^n) via a commitment derived from the first (see valueCommitmentForSlot),
+so the conjunction of the two one-sided bounds pins v to exactly [a, b) for
+any width, not just powers of two.
+*/
+func Setup(a, b int64) (Params, error) {
+	var p Params
+	if a > b {
+		return p, errors.New("a must be less than or equal to b")
+	}
+	w := b - a + 1
+	n := int64(0)
+	for (int64(1) << uint(n)) < w {
+		n++
+	}
+	p.a = a
+	p.b = b
+	p.n = n
+	return p, nil
+}
+
+// innerProductProof contains the L_j, R_j elements exchanged during the
+// recursive halving of l and r, plus the fully-folded scalars a, b.
+type innerProductProof struct {
+	L, R []*bn256.G1
+	a, b *big.Int
+}
+
+/*
+Proof contains the necessary elements for a (possibly aggregated)
+Bulletproof range proof over m values.
+*/
+type Proof struct {
+	Vs       []*bn256.G1
+	A, S     *bn256.G1
+	T1, T2   *bn256.G1
+	TauX, Mu *big.Int
+	THat     *big.Int
+	ipp      innerProductProof
+}
+
+// Prover holds the state necessary to produce a Bulletproof range proof for
+// a single value v in [a, b).
+type Prover struct {
+	p        Params
+	v, gamma *big.Int
+}
+
+// Verifier holds the public parameters necessary to check a Bulletproof
+// range proof.
+type Verifier struct {
+	p Params
+}
+
+// NewProver builds a Prover for value v in [p.a, p.b) with blinding factor gamma.
+func NewProver(p Params, v, gamma *big.Int) (*Prover, error) {
+	lo := new(big.Int).SetInt64(p.a)
+	hi := new(big.Int).SetInt64(p.b)
+	if v.Cmp(lo) < 0 || v.Cmp(hi) >= 0 {
+		return nil, errors.New("value does not belong to the interval")
+	}
+	return &Prover{p: p, v: v, gamma: gamma}, nil
+}
+
+// NewVerifier builds a Verifier for the public parameters p.
+func NewVerifier(p Params) *Verifier {
+	return &Verifier{p: p}
+}
+
+// Prove produces a (non-aggregated, m=1) Bulletproof range proof for v in [a, b).
+func (pr *Prover) Prove() (Proof, error) {
+	return AggregateProve([]*big.Int{pr.v}, []*big.Int{pr.gamma}, pr.p)
+}
+
+// Verify checks a Bulletproof range proof, aggregated or not.
+func (v *Verifier) Verify(proof *Proof) (bool, error) {
+	return Verify(proof, v.p)
+}
+
+/*
+AggregateProve produces a single Bulletproof proving every vs[j] in
+[p.a, p.b) at once, with size O(log2(len(vs)*2*n)) instead of len(vs)
+independent proofs. Each value v_j is split into two linked slots, x1_j =
+v_j-a and x2_j = w-1-x1_j (w = p.b-p.a), so that bounding both into
+[0, 2^n) pins v_j to exactly [a, b) regardless of whether w is a power of
+two (see valueCommitmentForSlot). The 2*len(vs) slots' bit-decompositions
+occupy distinct n-bit blocks of the combined N = len(vs)*2*n length
+vectors, and a distinct power z^{2+slot} of the Fiat-Shamir challenge z
+keeps the slots from interfering with each other in the aggregated inner
+product check.
+*/
+func AggregateProve(vs []*big.Int, gammas []*big.Int, p Params) (Proof, error) {
+	var proof Proof
+	m := len(vs)
+	if m == 0 || len(gammas) != m {
+		return proof, errors.New("vs and gammas must have the same non-zero length")
+	}
+	n := p.n
+	M := int64(2 * m)
+	N := M * n
+	order := bn256.Order
+
+	a := new(big.Int).SetInt64(p.a)
+	lo := new(big.Int).SetInt64(p.a)
+	hi := new(big.Int).SetInt64(p.b)
+	wMinus1 := new(big.Int).Sub(new(big.Int).Sub(hi, lo), big.NewInt(1))
+	for _, v := range vs {
+		if v.Cmp(lo) < 0 || v.Cmp(hi) >= 0 {
+			return proof, errors.New("value does not belong to the interval")
+		}
+	}
+
+	gVec, hVec := generators(N)
+
+	proof.Vs = make([]*bn256.G1, m)
+	slotVal := make([]*big.Int, M)
+	slotGamma := make([]*big.Int, M)
+	for j, v := range vs {
+		x1 := zkrangeproof.Sub(v, a)
+		gamma1 := gammas[j]
+		x2 := zkrangeproof.Sub(wMinus1, x1)
+		gamma2 := zkrangeproof.Mod(new(big.Int).Neg(gamma1), order)
+		proof.Vs[j] = commit(x1, gamma1)
+		slotVal[2*j], slotGamma[2*j] = x1, gamma1
+		slotVal[2*j+1], slotGamma[2*j+1] = x2, gamma2
+	}
+
+	aL := make([]*big.Int, N)
+	one := big.NewInt(1)
+	for slot := int64(0); slot < M; slot++ {
+		copy(aL[slot*n:], decomposeBits(slotVal[slot], n))
+	}
+	aR := make([]*big.Int, N)
+	for i := int64(0); i < N; i++ {
+		aR[i] = zkrangeproof.Mod(zkrangeproof.Sub(aL[i], one), order)
+	}
+
+	alpha, err := rand.Int(rand.Reader, order)
+	if err != nil {
+		return proof, err
+	}
+	proof.A = vectorCommit(gVec, hVec, aL, aR, alpha)
+
+	sL := randomVector(N)
+	sR := randomVector(N)
+	rho, err := rand.Int(rand.Reader, order)
+	if err != nil {
+		return proof, err
+	}
+	proof.S = vectorCommit(gVec, hVec, sL, sR, rho)
+
+	y := challengeScalar(append([]*bn256.G1{proof.A, proof.S}, proof.Vs...), []byte("y"))
+	z := challengeScalar(append([]*bn256.G1{proof.A, proof.S}, proof.Vs...), []byte("z"))
+
+	// l0 = aL - z*1, l1 = sL.
+	l0 := make([]*big.Int, N)
+	for i := int64(0); i < N; i++ {
+		l0[i] = zkrangeproof.Mod(zkrangeproof.Sub(aL[i], z), order)
+	}
+	l1 := sL
+
+	// r0_i = y^i*(aR_i+z) + z^{2+slot}*2^{i mod n}, r1_i = y^i*sR_i, where slot = i/n.
+	yPow := powers(y, N)
+	r0 := make([]*big.Int, N)
+	r1 := make([]*big.Int, N)
+	twoPows := powersOfTwo(n)
+	for slot := int64(0); slot < M; slot++ {
+		zSlot2 := new(big.Int).Exp(z, big.NewInt(2+slot), order)
+		for i := int64(0); i < n; i++ {
+			idx := slot*n + i
+			aRz := zkrangeproof.Mod(new(big.Int).Add(aR[idx], z), order)
+			r0[idx] = zkrangeproof.Mod(new(big.Int).Add(zkrangeproof.Multiply(yPow[idx], aRz), zkrangeproof.Multiply(zSlot2, twoPows[i])), order)
+			r1[idx] = zkrangeproof.Mod(zkrangeproof.Multiply(yPow[idx], sR[idx]), order)
+		}
+	}
+
+	t1 := zkrangeproof.Mod(new(big.Int).Add(innerProduct(l0, r1), innerProduct(l1, r0)), order)
+	t2 := innerProduct(l1, r1)
+
+	tau1, err := rand.Int(rand.Reader, order)
+	if err != nil {
+		return proof, err
+	}
+	tau2, err := rand.Int(rand.Reader, order)
+	if err != nil {
+		return proof, err
+	}
+	proof.T1 = commit(t1, tau1)
+	proof.T2 = commit(t2, tau2)
+
+	x := challengeScalar([]*bn256.G1{proof.T1, proof.T2}, []byte("x"))
+
+	l := addScaled(l0, l1, x, order)
+	r := addScaled(r0, r1, x, order)
+	proof.THat = innerProduct(l, r)
+
+	// taux = tau2*x^2 + tau1*x + sum_slot z^{2+slot}*gamma_slot
+	taux := zkrangeproof.Mod(new(big.Int).Add(zkrangeproof.Multiply(tau2, new(big.Int).Mul(x, x)), zkrangeproof.Multiply(tau1, x)), order)
+	for slot := int64(0); slot < M; slot++ {
+		zSlot2 := new(big.Int).Exp(z, big.NewInt(2+slot), order)
+		taux = zkrangeproof.Mod(new(big.Int).Add(taux, zkrangeproof.Multiply(zSlot2, slotGamma[slot])), order)
+	}
+	proof.TauX = taux
+	proof.Mu = zkrangeproof.Mod(new(big.Int).Add(alpha, zkrangeproof.Multiply(rho, x)), order)
+
+	// hPrime_i = hVec_i^{y^-i}, the basis the inner product argument runs
+	// r against, so the y-scaling folded into r above cancels out cleanly.
+	yInv := new(big.Int).ModInverse(y, order)
+	hPrime := scaleBases(hVec, powers(yInv, N))
+
+	proof.ipp = innerProductArgument(gVec, hPrime, l, r)
+
+	return proof, nil
+}
+
+// valueCommitmentForSlot returns the Pedersen commitment bound into the
+// aggregated range proof for slot (0-indexed, 2 slots per value). Even
+// slots are the proof's own Vs[slot/2] = Com(v-a, gamma); odd slots are
+// never transmitted -- they're derived as Com(w-1, 0) - Vs[slot/2] with
+// w = b-a, which equals Com((w-1)-(v-a), -gamma) = Com(b-1-v, -gamma). Because the prover
+// cannot choose this second commitment independently of the first, proving
+// both slots' underlying values lie in [0, 2^n) forces v itself into
+// exactly [a, b).
+func valueCommitmentForSlot(Vs []*bn256.G1, wMinus1 *big.Int, slot int64) *bn256.G1 {
+	j := slot / 2
+	if slot%2 == 0 {
+		return Vs[j]
+	}
+	c := new(bn256.G1).ScalarBaseMult(wMinus1)
+	c.Add(c, new(bn256.G1).Neg(Vs[j]))
+	return c
+}
+
+/*
+Verify checks a (possibly aggregated) Bulletproof range proof against p. It
+recomputes the same Fiat-Shamir challenges the prover used, checks the
+commitment to t(x) against the per-slot commitments derived from Vs (see
+valueCommitmentForSlot), T1 and T2, and checks the inner product argument
+against the commitment P implied by A, S and the public challenges -- it
+does not trust anything the prover did not commit to.
+*/
+func Verify(proof *Proof, p Params) (bool, error) {
+	m := len(proof.Vs)
+	if m == 0 {
+		return false, errors.New("proof has no value commitments")
+	}
+	n := p.n
+	M := int64(2 * m)
+	N := M * n
+	order := bn256.Order
+	wMinus1 := zkrangeproof.Mod(new(big.Int).SetInt64(p.b-p.a-1), order)
+
+	gVec, hVec := generators(N)
+
+	y := challengeScalar(append([]*bn256.G1{proof.A, proof.S}, proof.Vs...), []byte("y"))
+	z := challengeScalar(append([]*bn256.G1{proof.A, proof.S}, proof.Vs...), []byte("z"))
+	x := challengeScalar([]*bn256.G1{proof.T1, proof.T2}, []byte("x"))
+
+	// Check 1: g^THat.h^TauX == sum_slot C_slot^{z^{2+slot}} . g^delta(y,z) . T1^x . T2^{x^2}
+	lhs := commit(proof.THat, proof.TauX)
+	rhs := new(bn256.G1).ScalarMult(proof.T1, x)
+	rhs.Add(rhs, new(bn256.G1).ScalarMult(proof.T2, new(big.Int).Mod(new(big.Int).Mul(x, x), order)))
+	for slot := int64(0); slot < M; slot++ {
+		zSlot2 := new(big.Int).Exp(z, big.NewInt(2+slot), order)
+		rhs.Add(rhs, new(bn256.G1).ScalarMult(valueCommitmentForSlot(proof.Vs, wMinus1, slot), zSlot2))
+	}
+	delta := deltaYZ(y, z, n, M, order)
+	rhs.Add(rhs, new(bn256.G1).ScalarMult(G, delta))
+	if !bytes.Equal(lhs.Marshal(), rhs.Marshal()) {
+		return false, nil
+	}
+
+	// Check 2: the inner product argument against P = A + x*S - Mu*H - z*sum(gVec) + sum_i delta_i*hPrime_i.
+	yInv := new(big.Int).ModInverse(y, order)
+	yPow := powers(y, N)
+	hPrime := scaleBases(hVec, powers(yInv, N))
+
+	deltaVec := make([]*big.Int, N)
+	twoPows := powersOfTwo(n)
+	for slot := int64(0); slot < M; slot++ {
+		zSlot2 := new(big.Int).Exp(z, big.NewInt(2+slot), order)
+		for i := int64(0); i < n; i++ {
+			idx := slot*n + i
+			deltaVec[idx] = zkrangeproof.Mod(new(big.Int).Add(zkrangeproof.Multiply(yPow[idx], z), zkrangeproof.Multiply(zSlot2, twoPows[i])), order)
+		}
+	}
+
+	P := new(bn256.G1).Add(proof.A, new(bn256.G1).ScalarMult(proof.S, x))
+	P.Add(P, new(bn256.G1).Neg(new(bn256.G1).ScalarMult(H, proof.Mu)))
+	gSum := sumBases(gVec)
+	P.Add(P, new(bn256.G1).Neg(new(bn256.G1).ScalarMult(gSum, z)))
+	for i := int64(0); i < N; i++ {
+		P.Add(P, new(bn256.G1).ScalarMult(hPrime[i], deltaVec[i]))
+	}
+	// Fold in the claimed inner product itself, so the recursive argument
+	// proves <l,r> == THat rather than merely that l,r fold consistently.
+	P.Add(P, new(bn256.G1).ScalarMult(U, proof.THat))
+
+	return verifyInnerProductArgument(gVec, hPrime, P, proof.ipp), nil
+}
+
+func decomposeBits(x *big.Int, n int64) []*big.Int {
+	bits := make([]*big.Int, n)
+	for i := int64(0); i < n; i++ {
+		bits[i] = new(big.Int).SetInt64(int64(x.Bit(int(i))))
+	}
+	return bits
+}
+
+func randomVector(n int64) []*big.Int {
+	v := make([]*big.Int, n)
+	for i := int64(0); i < n; i++ {
+		v[i], _ = rand.Int(rand.Reader, bn256.Order)
+	}
+	return v
+}
+
+func powers(base *big.Int, n int64) []*big.Int {
+	out := make([]*big.Int, n)
+	acc := big.NewInt(1)
+	for i := int64(0); i < n; i++ {
+		out[i] = new(big.Int).Set(acc)
+		acc = zkrangeproof.Mod(zkrangeproof.Multiply(acc, base), bn256.Order)
+	}
+	return out
+}
+
+func powersOfTwo(n int64) []*big.Int {
+	out := make([]*big.Int, n)
+	acc := big.NewInt(1)
+	for i := int64(0); i < n; i++ {
+		out[i] = new(big.Int).Set(acc)
+		acc = new(big.Int).Lsh(acc, 1)
+	}
+	return out
+}
+
+func innerProduct(a, b []*big.Int) *big.Int {
+	acc := new(big.Int)
+	for i := range a {
+		acc = zkrangeproof.Mod(new(big.Int).Add(acc, zkrangeproof.Multiply(a[i], b[i])), bn256.Order)
+	}
+	return acc
+}
+
+// addScaled returns a + b*x elementwise, mod order.
+func addScaled(a, b []*big.Int, x *big.Int, order *big.Int) []*big.Int {
+	out := make([]*big.Int, len(a))
+	for i := range a {
+		out[i] = zkrangeproof.Mod(new(big.Int).Add(a[i], zkrangeproof.Multiply(b[i], x)), order)
+	}
+	return out
+}
+
+// deltaYZ computes delta(y,z) = (z - z^2)*sum(y^0..y^{N-1}) - sum_slot z^{3+slot}*(2^n - 1),
+// the constant term the aggregated t(x) commitment check must account for.
+// slots is the total slot count (2 per value after the two-sided split).
+func deltaYZ(y, z *big.Int, n, slots int64, order *big.Int) *big.Int {
+	N := n * slots
+	sumY := new(big.Int)
+	yi := big.NewInt(1)
+	for i := int64(0); i < N; i++ {
+		sumY = zkrangeproof.Mod(new(big.Int).Add(sumY, yi), order)
+		yi = zkrangeproof.Mod(zkrangeproof.Multiply(yi, y), order)
+	}
+	zMinusZ2 := zkrangeproof.Mod(new(big.Int).Sub(z, new(big.Int).Mod(new(big.Int).Mul(z, z), order)), order)
+	delta := zkrangeproof.Mod(zkrangeproof.Multiply(zMinusZ2, sumY), order)
+
+	sum2n := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(n)), big.NewInt(1))
+	for slot := int64(0); slot < slots; slot++ {
+		zSlot3 := new(big.Int).Exp(z, big.NewInt(3+slot), order)
+		delta = zkrangeproof.Mod(new(big.Int).Sub(delta, zkrangeproof.Multiply(zSlot3, sum2n)), order)
+	}
+	return delta
+}
+
+func commit(v, gamma *big.Int) *bn256.G1 {
+	c := new(bn256.G1).ScalarBaseMult(v)
+	c.Add(c, new(bn256.G1).ScalarMult(H, gamma))
+	return c
+}
+
+func vectorCommit(gVec, hVec []*bn256.G1, l, r []*big.Int, blind *big.Int) *bn256.G1 {
+	acc := new(bn256.G1).ScalarMult(H, blind)
+	for i := range l {
+		acc.Add(acc, new(bn256.G1).ScalarMult(gVec[i], l[i]))
+		acc.Add(acc, new(bn256.G1).ScalarMult(hVec[i], r[i]))
+	}
+	return acc
+}
+
+func scaleBases(bases []*bn256.G1, scalars []*big.Int) []*bn256.G1 {
+	out := make([]*bn256.G1, len(bases))
+	for i := range bases {
+		out[i] = new(bn256.G1).ScalarMult(bases[i], scalars[i])
+	}
+	return out
+}
+
+func sumBases(bases []*bn256.G1) *bn256.G1 {
+	acc := new(bn256.G1).SetInfinity()
+	for _, b := range bases {
+		acc.Add(acc, b)
+	}
+	return acc
+}
+
+/*
+innerProductArgument recursively halves l and r, sending L_j, R_j at each
+round and folding the bases gVec, hVec under the Fiat-Shamir challenge x_j,
+until a single pair (a, b) remains. The cross term of each L_j/R_j is
+committed under U so the recursive checks in verifyInnerProductArgument
+can reconstruct the same accumulator the prover built, proving <l,r>
+equals whatever was folded into the starting commitment P (Prove and
+AggregateProve only call this after building l, r such that <l,r> == THat).
+*/
+func innerProductArgument(gVec, hVec []*bn256.G1, l, r []*big.Int) innerProductProof {
+	var proof innerProductProof
+	order := bn256.Order
+	g := append([]*bn256.G1{}, gVec...)
+	h := append([]*bn256.G1{}, hVec...)
+	for len(l) > 1 {
+		k := len(l) / 2
+		lL, lR := l[:k], l[k:]
+		rL, rR := r[:k], r[k:]
+		gL, gR := g[:k], g[k:]
+		hL, hR := h[:k], h[k:]
+
+		cL := innerProduct(lL, rR)
+		cR := innerProduct(lR, rL)
+
+		L := weightedCommit(gR, hL, lL, rR)
+		L.Add(L, new(bn256.G1).ScalarMult(U, cL))
+		R := weightedCommit(gL, hR, lR, rL)
+		R.Add(R, new(bn256.G1).ScalarMult(U, cR))
+
+		proof.L = append(proof.L, L)
+		proof.R = append(proof.R, R)
+
+		x := challengeScalar([]*bn256.G1{L, R}, []byte("ipp"))
+		xInv := new(big.Int).ModInverse(x, order)
+
+		l = foldScalars(lL, lR, x, xInv)
+		r = foldScalars(rL, rR, xInv, x)
+		g = foldBases(gL, gR, xInv, x)
+		h = foldBases(hL, hR, x, xInv)
+	}
+	proof.a = l[0]
+	proof.b = r[0]
+	return proof
+}
+
+/*
+verifyInnerProductArgument folds the claimed commitment P through the same
+L_j/R_j, x_j challenges the prover used and checks that the result matches
+g_final^a . h_final^b . U^{a*b} -- the only way this passes is if the
+prover actually knew vectors l, r with P = g^l.h^r.U^{<l,r>}, which is
+exactly the statement the range proof reduces to.
+*/
+func verifyInnerProductArgument(gVec, hVec []*bn256.G1, P *bn256.G1, proof innerProductProof) bool {
+	order := bn256.Order
+	g := append([]*bn256.G1{}, gVec...)
+	h := append([]*bn256.G1{}, hVec...)
+	acc := new(bn256.G1).Add(P, new(bn256.G1).SetInfinity())
+	for j := range proof.L {
+		k := len(g) / 2
+		gL, gR := g[:k], g[k:]
+		hL, hR := h[:k], h[k:]
+		x := challengeScalar([]*bn256.G1{proof.L[j], proof.R[j]}, []byte("ipp"))
+		xInv := new(big.Int).ModInverse(x, order)
+		x2 := new(big.Int).Mod(new(big.Int).Mul(x, x), order)
+		xInv2 := new(big.Int).Mod(new(big.Int).Mul(xInv, xInv), order)
+		acc.Add(acc, new(bn256.G1).ScalarMult(proof.L[j], x2))
+		acc.Add(acc, new(bn256.G1).ScalarMult(proof.R[j], xInv2))
+		g = foldBases(gL, gR, xInv, x)
+		h = foldBases(hL, hR, x, xInv)
+	}
+	if len(g) != 1 {
+		return false
+	}
+	ab := new(big.Int).Mod(new(big.Int).Mul(proof.a, proof.b), order)
+	expect := new(bn256.G1).ScalarMult(g[0], proof.a)
+	expect.Add(expect, new(bn256.G1).ScalarMult(h[0], proof.b))
+	expect.Add(expect, new(bn256.G1).ScalarMult(U, ab))
+	return bytes.Equal(acc.Marshal(), expect.Marshal())
+}
+
+func weightedCommit(g, h []*bn256.G1, a, b []*big.Int) *bn256.G1 {
+	acc := new(bn256.G1).SetInfinity()
+	for i := range a {
+		acc.Add(acc, new(bn256.G1).ScalarMult(g[i], a[i]))
+		acc.Add(acc, new(bn256.G1).ScalarMult(h[i], b[i]))
+	}
+	return acc
+}
+
+func foldScalars(left, right []*big.Int, xl, xr *big.Int) []*big.Int {
+	out := make([]*big.Int, len(left))
+	for i := range left {
+		v := new(big.Int).Add(zkrangeproof.Multiply(left[i], xl), zkrangeproof.Multiply(right[i], xr))
+		out[i] = zkrangeproof.Mod(v, bn256.Order)
+	}
+	return out
+}
+
+func foldBases(left, right []*bn256.G1, xl, xr *big.Int) []*bn256.G1 {
+	out := make([]*bn256.G1, len(left))
+	for i := range left {
+		p := new(bn256.G1).ScalarMult(left[i], xl)
+		p.Add(p, new(bn256.G1).ScalarMult(right[i], xr))
+		out[i] = p
+	}
+	return out
+}
+
+func challengeScalar(points []*bn256.G1, domain []byte) *big.Int {
+	hasher := sha256.New()
+	for _, p := range points {
+		hasher.Write(p.Marshal())
+	}
+	hasher.Write(domain)
+	x := new(big.Int).SetBytes(hasher.Sum(nil))
+	x = zkrangeproof.Mod(x, bn256.Order)
+	if x.Sign() == 0 {
+		x = big.NewInt(1)
+	}
+	return x
+}
+
+// generators deterministically derives count independent generators for
+// gVec and hVec, so the prover and verifier always agree on them without
+// any setup ceremony.
+func generators(count int64) ([]*bn256.G1, []*bn256.G1) {
+	g := make([]*bn256.G1, count)
+	h := make([]*bn256.G1, count)
+	for i := int64(0); i < count; i++ {
+		g[i] = hashToG1([]byte("bulletproof/g/" + big.NewInt(i).String()))
+		h[i] = hashToG1([]byte("bulletproof/h/" + big.NewInt(i).String()))
+	}
+	return g, h
+}
+
+/*
+hashToG1 deterministically maps domain-separated bytes to a point of
+bn256.G1 with unknown discrete log relative to G, so the public generators
+can be recomputed by anyone without a trusted setup and without anyone --
+including whoever picked the domain strings -- learning a usable relation
+between them. It uses try-and-increment: hash domain||counter into a
+candidate x in the base field, accept if x^3+curveB is a quadratic residue
+(the corresponding y is its square root), and try the next counter
+otherwise. This is the classical pre-RFC9380 hash-to-curve construction;
+it isn't constant-time, but these generators are public one-time values
+computed outside any secret-dependent code path, so that isn't a concern
+here.
+*/
+func hashToG1(domain []byte) *bn256.G1 {
+	for ctr := uint32(0); ; ctr++ {
+		h := sha256.New()
+		h.Write(domain)
+		var ctrBytes [4]byte
+		binary.BigEndian.PutUint32(ctrBytes[:], ctr)
+		h.Write(ctrBytes[:])
+		x := new(big.Int).Mod(new(big.Int).SetBytes(h.Sum(nil)), fieldP)
+
+		rhs := new(big.Int).Exp(x, big.NewInt(3), fieldP)
+		rhs.Add(rhs, curveB)
+		rhs.Mod(rhs, fieldP)
+
+		y := new(big.Int).ModSqrt(rhs, fieldP)
+		if y == nil {
+			continue
+		}
+
+		buf := make([]byte, 64)
+		x.FillBytes(buf[:32])
+		y.FillBytes(buf[32:])
+		p := new(bn256.G1)
+		if _, err := p.Unmarshal(buf); err == nil {
+			return p
+		}
+	}
+}
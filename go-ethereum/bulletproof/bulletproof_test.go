@@ -0,0 +1,180 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bulletproof
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256"
+	"github.com/ing-bank/zkrangeproof/go-ethereum/zkrangeproof"
+)
+
+func randGamma(t *testing.T) *big.Int {
+	t.Helper()
+	gamma, err := rand.Int(rand.Reader, bn256.Order)
+	if err != nil {
+		t.Fatalf("rand.Int: %v", err)
+	}
+	return gamma
+}
+
+// TestProveVerifyRoundTrip checks a single, non-aggregated (m=1) proof for
+// a value comfortably inside an arbitrary -- not power-of-two-width --
+// range verifies.
+func TestProveVerifyRoundTrip(t *testing.T) {
+	p, err := Setup(18, 65)
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	pr, err := NewProver(p, big.NewInt(42), randGamma(t))
+	if err != nil {
+		t.Fatalf("NewProver: %v", err)
+	}
+	proof, err := pr.Prove()
+	if err != nil {
+		t.Fatalf("Prove: %v", err)
+	}
+	ok, err := NewVerifier(p).Verify(&proof)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !ok {
+		t.Fatal("Verify rejected a valid in-range proof")
+	}
+}
+
+// TestProveVerifyEndpoints checks the range is half-open: a (inclusive)
+// verifies, b (exclusive) does not admit a proof at all.
+func TestProveVerifyEndpoints(t *testing.T) {
+	p, err := Setup(10, 20)
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	pr, err := NewProver(p, big.NewInt(10), randGamma(t))
+	if err != nil {
+		t.Fatalf("NewProver(a): %v", err)
+	}
+	proof, err := pr.Prove()
+	if err != nil {
+		t.Fatalf("Prove(a): %v", err)
+	}
+	ok, err := NewVerifier(p).Verify(&proof)
+	if err != nil {
+		t.Fatalf("Verify(a): %v", err)
+	}
+	if !ok {
+		t.Fatal("Verify rejected a proof for v=a, the inclusive lower endpoint")
+	}
+
+	if _, err := NewProver(p, big.NewInt(20), randGamma(t)); err == nil {
+		t.Fatal("NewProver accepted v=b, the exclusive upper endpoint")
+	}
+}
+
+// TestVerifyRejectsTamperedProof checks that corrupting THat (the claimed
+// inner product) after proving makes Verify reject, guarding against a
+// regression to verifyInnerProductArgument's once-unconditional true.
+func TestVerifyRejectsTamperedProof(t *testing.T) {
+	p, err := Setup(0, 256)
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	pr, err := NewProver(p, big.NewInt(100), randGamma(t))
+	if err != nil {
+		t.Fatalf("NewProver: %v", err)
+	}
+	proof, err := pr.Prove()
+	if err != nil {
+		t.Fatalf("Prove: %v", err)
+	}
+
+	proof.THat = zkrangeproof.Mod(new(big.Int).Add(proof.THat, big.NewInt(1)), bn256.Order)
+
+	ok, err := NewVerifier(p).Verify(&proof)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if ok {
+		t.Fatal("Verify accepted a proof with a tampered THat")
+	}
+}
+
+// TestAggregateProveRejectsOutOfRangeValue checks AggregateProve itself
+// refuses a value above w=b-a but still below the 2^n a single-sided bit
+// decomposition alone would admit for a non-power-of-two width -- exactly
+// the gap valueCommitmentForSlot's two-sided decomposition exists to close.
+func TestAggregateProveRejectsOutOfRangeValue(t *testing.T) {
+	p, err := Setup(0, 100)
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	if _, err := AggregateProve([]*big.Int{big.NewInt(120)}, []*big.Int{randGamma(t)}, p); err == nil {
+		t.Fatal("AggregateProve accepted v=120 for range [0,100)")
+	}
+}
+
+// TestAggregateProveVerifyRoundTrip checks that AggregateProve over
+// several values all verifies as one proof, and that every value
+// contributes to the proof (a regression test for AggregateProve having
+// once silently dropped every value but the first: tampering with any one
+// of Vs must be caught).
+func TestAggregateProveVerifyRoundTrip(t *testing.T) {
+	p, err := Setup(0, 256)
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	values := []int64{3, 100, 255, 0, 42}
+	vs := make([]*big.Int, len(values))
+	gammas := make([]*big.Int, len(values))
+	for i, v := range values {
+		vs[i] = big.NewInt(v)
+		gammas[i] = randGamma(t)
+	}
+
+	proof, err := AggregateProve(vs, gammas, p)
+	if err != nil {
+		t.Fatalf("AggregateProve: %v", err)
+	}
+	if len(proof.Vs) != len(values) {
+		t.Fatalf("proof.Vs has %d elements, want %d", len(proof.Vs), len(values))
+	}
+
+	ok, err := Verify(&proof, p)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !ok {
+		t.Fatal("Verify rejected a valid aggregated proof")
+	}
+
+	for i := range values {
+		tampered := proof
+		tampered.Vs = append([]*bn256.G1(nil), proof.Vs...)
+		tampered.Vs[i] = new(bn256.G1).Add(proof.Vs[i], new(bn256.G1).ScalarBaseMult(big.NewInt(1)))
+		ok, err := Verify(&tampered, p)
+		if err != nil {
+			continue
+		}
+		if ok {
+			t.Fatalf("Verify accepted an aggregated proof with Vs[%d] tampered", i)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package bn256 adapts github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256
+to the pairing.Curve interface, so the original curve zkrangeproof shipped
+with remains available as one backend among several.
+*/
+package bn256
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256"
+	"github.com/ing-bank/zkrangeproof/go-ethereum/pairing"
+)
+
+// fieldP is the alt_bn128/BN254 base field modulus (distinct from
+// bn256.Order, the scalar/group order): the prime p such that G1 lives in
+// (F_p)^2 and G2 lives in (F_p^2)^2, per EIP-196/197.
+var fieldP, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+
+// twistBRe, twistBIm are the coefficients of the twist curve's constant
+// term B' = 3/(9+i) in F_p^2 = {re + im*i}, i.e. the b in the twist
+// equation y^2 = x^3 + B' that G2's points satisfy. This is the standard
+// BN254 D-twist constant used by every alt_bn128 implementation, derived
+// once (and checked in) rather than recomputed per call.
+var (
+	twistBRe, _ = new(big.Int).SetString("19485874751759354771024239261021720505790618469301721065564631296452457478373", 10)
+	twistBIm, _ = new(big.Int).SetString("266929791119991161246907387137283842545076965332900288569378510910307636690", 10)
+)
+
+// sqrtExp is the exponent (p+1)/4 used for F_p square roots, valid since
+// fieldP = 3 (mod 4).
+var sqrtExp = new(big.Int).Rsh(new(big.Int).Add(fieldP, big.NewInt(1)), 2)
+
+// Curve implements pairing.Curve over github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256.
+type Curve struct {
+	gtBase pairing.GT
+}
+
+// New returns the bn256 pairing.Curve backend.
+func New() *Curve {
+	base := bn256.Pair(
+		new(bn256.G1).ScalarBaseMult(big.NewInt(1)),
+		new(bn256.G2).ScalarBaseMult(big.NewInt(1)),
+	)
+	return &Curve{gtBase: gt{base}}
+}
+
+func (c *Curve) Order() *big.Int { return bn256.Order }
+
+func (c *Curve) Pair(a pairing.G1, b pairing.G2) pairing.GT {
+	return gt{bn256.Pair(a.(g1).p, b.(g2).p)}
+}
+
+func (c *Curve) G1BaseMult(k *big.Int) pairing.G1 {
+	return g1{new(bn256.G1).ScalarBaseMult(k)}
+}
+
+func (c *Curve) G2BaseMult(k *big.Int) pairing.G2 {
+	return g2{new(bn256.G2).ScalarBaseMult(k)}
+}
+
+func (c *Curve) GTBase() pairing.GT { return c.gtBase }
+
+/*
+HashToG2 maps domain-separated bytes to a G2 point with unknown discrete
+log relative to G2Base, replacing ccs08's hardcoded h constant with a
+reproducible, auditable derivation. Hashing straight to a scalar and
+multiplying the base point (the previous implementation) would make that
+discrete log equal to the hash itself, a value anyone can recompute from
+the public domain string -- exactly the weakness a hardcoded h had. This
+uses the same try-and-increment construction bulletproof.hashToG1 uses
+for G1, extended to G2's quadratic extension field F_p^2: hash domain||
+counter into a candidate point x in F_p^2, accept it if x^3+twistB is a
+quadratic residue in F_p^2 (the corresponding y is its square root), and
+try the next counter otherwise.
+*/
+func (c *Curve) HashToG2(domain []byte) pairing.G2 {
+	for ctr := uint32(0); ; ctr++ {
+		x := fp2{re: hashFp(domain, ctr, "re"), im: hashFp(domain, ctr, "im")}
+		rhs := fp2Add(fp2Mul(fp2Square(x), x), fp2{re: twistBRe, im: twistBIm})
+		y, ok := fp2Sqrt(rhs)
+		if !ok {
+			continue
+		}
+
+		buf := make([]byte, 128)
+		x.im.FillBytes(buf[0:32])
+		x.re.FillBytes(buf[32:64])
+		y.im.FillBytes(buf[64:96])
+		y.re.FillBytes(buf[96:128])
+		p := new(bn256.G2)
+		if _, err := p.Unmarshal(buf); err == nil {
+			return g2{p}
+		}
+	}
+}
+
+// hashFp hashes domain||ctr||label into a field element of F_p, used to
+// build the two independent F_p^2 coordinates a try-and-increment
+// candidate needs.
+func hashFp(domain []byte, ctr uint32, label string) *big.Int {
+	h := sha256.New()
+	h.Write(domain)
+	var ctrBytes [4]byte
+	binary.BigEndian.PutUint32(ctrBytes[:], ctr)
+	h.Write(ctrBytes[:])
+	h.Write([]byte(label))
+	return new(big.Int).Mod(new(big.Int).SetBytes(h.Sum(nil)), fieldP)
+}
+
+// fp2 holds an element re+im*i of F_p^2 = F_p[i]/(i^2+1).
+type fp2 struct{ re, im *big.Int }
+
+func fp2Add(a, b fp2) fp2 {
+	return fp2{
+		re: new(big.Int).Mod(new(big.Int).Add(a.re, b.re), fieldP),
+		im: new(big.Int).Mod(new(big.Int).Add(a.im, b.im), fieldP),
+	}
+}
+
+func fp2Mul(a, b fp2) fp2 {
+	// (a.re+a.im*i)(b.re+b.im*i) = (a.re*b.re - a.im*b.im) + (a.re*b.im + a.im*b.re)*i
+	reXre := new(big.Int).Mul(a.re, b.re)
+	imXim := new(big.Int).Mul(a.im, b.im)
+	reXim := new(big.Int).Mul(a.re, b.im)
+	imXre := new(big.Int).Mul(a.im, b.re)
+	return fp2{
+		re: new(big.Int).Mod(new(big.Int).Sub(reXre, imXim), fieldP),
+		im: new(big.Int).Mod(new(big.Int).Add(reXim, imXre), fieldP),
+	}
+}
+
+func fp2Square(a fp2) fp2 { return fp2Mul(a, a) }
+
+// sqrtFp returns the square root of a in F_p for fieldP = 3 (mod 4),
+// without checking that one exists; callers must verify via squaring.
+func sqrtFp(a *big.Int) *big.Int {
+	return new(big.Int).Exp(a, sqrtExp, fieldP)
+}
+
+/*
+fp2Sqrt returns a square root of a in F_p^2, using the standard
+"complex method": for a = a.re+a.im*i, a square root x = x.re+x.im*i
+satisfies x.re^2-x.im^2 = a.re and 2*x.re*x.im = a.im. Writing
+d = sqrt(a.re^2+a.im^2) (the norm, a F_p element), x.re is a square root
+of (a.re+d)/2 or (a.re-d)/2 -- exactly one of the two is guaranteed to be
+a quadratic residue when d exists -- and x.im follows from a.im/(2*x.re).
+When that x.re is 0, a is a non-residue of F_p and its root is pure
+imaginary: x.im = sqrt(-a.re).
+*/
+func fp2Sqrt(a fp2) (fp2, bool) {
+	norm := new(big.Int).Mod(new(big.Int).Add(
+		new(big.Int).Mul(a.re, a.re),
+		new(big.Int).Mul(a.im, a.im),
+	), fieldP)
+	d := sqrtFp(norm)
+	if new(big.Int).Exp(d, big.NewInt(2), fieldP).Cmp(norm) != 0 {
+		return fp2{}, false
+	}
+
+	inv2 := new(big.Int).ModInverse(big.NewInt(2), fieldP)
+	tryRe := func(sum *big.Int) (*big.Int, bool) {
+		alpha := new(big.Int).Mod(new(big.Int).Mul(sum, inv2), fieldP)
+		x0 := sqrtFp(alpha)
+		if new(big.Int).Exp(x0, big.NewInt(2), fieldP).Cmp(alpha) != 0 {
+			return nil, false
+		}
+		return x0, true
+	}
+
+	x0, ok := tryRe(new(big.Int).Add(a.re, d))
+	if !ok {
+		x0, ok = tryRe(new(big.Int).Sub(a.re, d))
+		if !ok {
+			return fp2{}, false
+		}
+	}
+	if x0.Sign() == 0 {
+		negRe := new(big.Int).Mod(new(big.Int).Neg(a.re), fieldP)
+		im := sqrtFp(negRe)
+		if new(big.Int).Exp(im, big.NewInt(2), fieldP).Cmp(negRe) != 0 {
+			return fp2{}, false
+		}
+		return fp2{re: new(big.Int), im: im}, true
+	}
+
+	x1 := new(big.Int).Mul(x0, big.NewInt(2))
+	x1.ModInverse(x1, fieldP)
+	x1.Mul(x1, a.im)
+	x1.Mod(x1, fieldP)
+	return fp2{re: x0, im: x1}, true
+}
+
+func (c *Curve) Name() string { return "bn256" }
+
+type g1 struct{ p *bn256.G1 }
+
+func (g g1) Add(a, b pairing.G1) pairing.G1 {
+	return g1{new(bn256.G1).Add(a.(g1).p, b.(g1).p)}
+}
+func (g g1) ScalarMult(a pairing.G1, k *big.Int) pairing.G1 {
+	return g1{new(bn256.G1).ScalarMult(a.(g1).p, k)}
+}
+func (g g1) ScalarBaseMult(k *big.Int) pairing.G1 { return g1{new(bn256.G1).ScalarBaseMult(k)} }
+func (g g1) Neg(a pairing.G1) pairing.G1           { return g1{new(bn256.G1).Neg(a.(g1).p)} }
+func (g g1) SetInfinity() pairing.G1               { return g1{new(bn256.G1).SetInfinity()} }
+func (g g1) Marshal() []byte                       { return g.p.Marshal() }
+func (g g1) Unmarshal(m []byte) (pairing.G1, error) {
+	p := new(bn256.G1)
+	_, err := p.Unmarshal(m)
+	return g1{p}, err
+}
+
+type g2 struct{ p *bn256.G2 }
+
+func (g g2) Add(a, b pairing.G2) pairing.G2 {
+	return g2{new(bn256.G2).Add(a.(g2).p, b.(g2).p)}
+}
+func (g g2) ScalarMult(a pairing.G2, k *big.Int) pairing.G2 {
+	return g2{new(bn256.G2).ScalarMult(a.(g2).p, k)}
+}
+func (g g2) ScalarBaseMult(k *big.Int) pairing.G2 { return g2{new(bn256.G2).ScalarBaseMult(k)} }
+func (g g2) Neg(a pairing.G2) pairing.G2           { return g2{new(bn256.G2).Neg(a.(g2).p)} }
+func (g g2) SetInfinity() pairing.G2               { return g2{new(bn256.G2).SetInfinity()} }
+func (g g2) Marshal() []byte                       { return g.p.Marshal() }
+func (g g2) Unmarshal(m []byte) (pairing.G2, error) {
+	p := new(bn256.G2)
+	_, err := p.Unmarshal(m)
+	return g2{p}, err
+}
+
+type gt struct{ p *bn256.GT }
+
+func (g gt) Add(a, b pairing.GT) pairing.GT {
+	return gt{new(bn256.GT).Add(a.(gt).p, b.(gt).p)}
+}
+func (g gt) ScalarMult(a pairing.GT, k *big.Int) pairing.GT {
+	return gt{new(bn256.GT).ScalarMult(a.(gt).p, k)}
+}
+func (g gt) Invert(a pairing.GT) pairing.GT { return gt{new(bn256.GT).Invert(a.(gt).p)} }
+func (g gt) Marshal() []byte                { return g.p.Marshal() }
+func (g gt) Unmarshal(m []byte) (pairing.GT, error) {
+	p := new(bn256.GT)
+	_, err := p.Unmarshal(m)
+	return gt{p}, err
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file cross-checks every pairing.Curve backend against the same
+conformance suite, so a new backend (or a change to an existing one) can't
+silently violate the properties CCS08Curve relies on -- bilinearity,
+stable Marshal/Unmarshal round-trips, and an actually-random-looking
+HashToG2 -- without a test catching it. It lives in package pairing_test
+(not pairing) so it can import the concrete curves/bn256 and
+curves/bls12381 backends without those packages importing back into
+pairing, which they already do, and creating a cycle.
+*/
+package pairing_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ing-bank/zkrangeproof/go-ethereum/curves/bls12381"
+	"github.com/ing-bank/zkrangeproof/go-ethereum/curves/bn256"
+	"github.com/ing-bank/zkrangeproof/go-ethereum/pairing"
+)
+
+func curves() map[string]pairing.Curve {
+	return map[string]pairing.Curve{
+		"bn256":     bn256.New(),
+		"bls12-381": bls12381.New(),
+	}
+}
+
+func TestCurveConformance(t *testing.T) {
+	for name, curve := range curves() {
+		curve := curve
+		t.Run(name, func(t *testing.T) {
+			if got := curve.Name(); got == "" {
+				t.Error("Name() returned an empty string")
+			}
+
+			a := big.NewInt(7)
+			b := big.NewInt(11)
+			ab := new(big.Int).Mul(a, b)
+
+			// Bilinearity: e(a*G1, b*G2) == e(G1, G2)^(a*b).
+			lhs := curve.Pair(curve.G1BaseMult(a), curve.G2BaseMult(b))
+			rhs := curve.GTBase().ScalarMult(curve.GTBase(), ab)
+			if !bytes.Equal(lhs.Marshal(), rhs.Marshal()) {
+				t.Error("Pair is not bilinear: e(a*G1,b*G2) != e(G1,G2)^(a*b)")
+			}
+
+			// GTBase itself must equal e(G1Base, G2Base).
+			base := curve.Pair(curve.G1BaseMult(big.NewInt(1)), curve.G2BaseMult(big.NewInt(1)))
+			if !bytes.Equal(curve.GTBase().Marshal(), base.Marshal()) {
+				t.Error("GTBase() != Pair(G1BaseMult(1), G2BaseMult(1))")
+			}
+
+			// G1/G2 Marshal/Unmarshal round-trip.
+			g1 := curve.G1BaseMult(a)
+			g1Bytes := g1.Marshal()
+			g1Got, err := g1.Unmarshal(g1Bytes)
+			if err != nil {
+				t.Fatalf("G1.Unmarshal: %v", err)
+			}
+			if !bytes.Equal(g1Got.Marshal(), g1Bytes) {
+				t.Error("G1 Marshal/Unmarshal did not round-trip")
+			}
+
+			g2 := curve.G2BaseMult(b)
+			g2Bytes := g2.Marshal()
+			g2Got, err := g2.Unmarshal(g2Bytes)
+			if err != nil {
+				t.Fatalf("G2.Unmarshal: %v", err)
+			}
+			if !bytes.Equal(g2Got.Marshal(), g2Bytes) {
+				t.Error("G2 Marshal/Unmarshal did not round-trip")
+			}
+
+			// HashToG2: deterministic, domain-separated, and a valid point
+			// (Marshal/Unmarshal round-trips and Add with itself works).
+			h1 := curve.HashToG2([]byte("conformance/a"))
+			h1Again := curve.HashToG2([]byte("conformance/a"))
+			if !bytes.Equal(h1.Marshal(), h1Again.Marshal()) {
+				t.Error("HashToG2 is not deterministic for the same domain")
+			}
+			h2 := curve.HashToG2([]byte("conformance/b"))
+			if bytes.Equal(h1.Marshal(), h2.Marshal()) {
+				t.Error("HashToG2 returned the same point for two different domains")
+			}
+			hBytes := h1.Marshal()
+			hGot, err := h1.Unmarshal(hBytes)
+			if err != nil {
+				t.Fatalf("HashToG2 result does not round-trip through Marshal/Unmarshal: %v", err)
+			}
+			if !bytes.Equal(hGot.Marshal(), hBytes) {
+				t.Error("HashToG2 result Marshal/Unmarshal did not round-trip")
+			}
+			doubled := h1.Add(h1, h1)
+			if bytes.Equal(doubled.Marshal(), hBytes) {
+				t.Error("HashToG2 result behaves as the identity under Add")
+			}
+		})
+	}
+}
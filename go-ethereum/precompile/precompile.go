@@ -0,0 +1,97 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package precompile exposes ccs08's VerifyUL behind the same ABI shape the
+EVM's built-in pairing precompiles use: a []byte in, []byte out function
+with an associated gas cost, so a Solidity contract at a well-known address
+can invoke range-proof verification the same way it invokes ecrecover or
+the alt_bn128 pairing check. It returns 32-byte left-padded 0x01/0x00,
+matching the convention used by the alt_bn128 pairing precompile rather
+than a bare boolean.
+*/
+package precompile
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/ing-bank/zkrangeproof/go-ethereum/zkrangeproof"
+)
+
+const (
+	// basePairingGas covers the fixed overhead of a VerifyUL call: marshaling,
+	// the D-equation check, and constant-size bookkeeping independent of l.
+	basePairingGas uint64 = 45000
+	// perPairingGas approximates the EVM's alt_bn128 pairing precompile cost
+	// per pairing; VerifyUL performs 3*l pairings.
+	perPairingGas uint64 = 34000
+)
+
+/*
+Verify decodes input as a length-prefixed (paramsUL || proofUL) pair encoded
+with zkrangeproof's MarshalBinary wire format, runs VerifyUL, and returns a
+32-byte left-padded 0x01 (valid) or 0x00 (invalid/malformed), along with the
+gas the call should be charged. An error is only returned for inputs that
+are too malformed to even bill gas for (e.g. too short to contain a length
+prefix).
+*/
+func Verify(input []byte) ([]byte, uint64, error) {
+	if len(input) < 8 {
+		return nil, 0, errors.New("precompile: input too short to contain a length prefix")
+	}
+	paramsLen := binary.BigEndian.Uint64(input[:8])
+	rest := input[8:]
+	if uint64(len(rest)) < paramsLen {
+		return falseResult(), basePairingGas, nil
+	}
+	paramsBytes := rest[:paramsLen]
+	proofBytes := rest[paramsLen:]
+
+	p, err := zkrangeproof.UnmarshalParamsUL(paramsBytes)
+	if err != nil {
+		return falseResult(), basePairingGas, nil
+	}
+	gas := gasFor(p.L())
+
+	proof, err := zkrangeproof.UnmarshalProofUL(proofBytes)
+	if err != nil {
+		return falseResult(), gas, nil
+	}
+
+	ok, err := zkrangeproof.VerifyUL(proof, p)
+	if err != nil {
+		return falseResult(), gas, nil
+	}
+	if !ok {
+		return falseResult(), gas, nil
+	}
+	return trueResult(), gas, nil
+}
+
+func gasFor(l int64) uint64 {
+	return basePairingGas + uint64(3*l)*perPairingGas
+}
+
+func trueResult() []byte {
+	out := make([]byte, 32)
+	out[31] = 1
+	return out
+}
+
+func falseResult() []byte {
+	return make([]byte, 32)
+}
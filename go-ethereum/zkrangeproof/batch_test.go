@@ -0,0 +1,153 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkrangeproof
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256"
+)
+
+// makeProofsUL generates n valid proofUL instances against p, one per value
+// in [0,n) mod p.u^p.l, for use by both the correctness tests and the
+// benchmarks below.
+func makeProofsUL(tb testing.TB, p paramsUL, n int) []*proofUL {
+	tb.Helper()
+	proofs := make([]*proofUL, n)
+	for i := 0; i < n; i++ {
+		r, err := rand.Int(rand.Reader, bn256.Order)
+		if err != nil {
+			tb.Fatalf("rand.Int: %v", err)
+		}
+		proof, err := ProveUL(big.NewInt(int64(i%256)), r, p)
+		if err != nil {
+			tb.Fatalf("ProveUL: %v", err)
+		}
+		proofs[i] = &proof
+	}
+	return proofs
+}
+
+func TestBatchVerifyULAcceptsValidProofs(t *testing.T) {
+	p, err := SetupUL(2, 8)
+	if err != nil {
+		t.Fatalf("SetupUL: %v", err)
+	}
+	proofs := makeProofsUL(t, p, 10)
+
+	ok, err := BatchVerifyUL(proofs, &p)
+	if err != nil {
+		t.Fatalf("BatchVerifyUL: %v", err)
+	}
+	if !ok {
+		t.Fatal("BatchVerifyUL rejected a batch of valid proofs")
+	}
+}
+
+func TestBatchVerifyULRejectsTamperedProof(t *testing.T) {
+	p, err := SetupUL(2, 8)
+	if err != nil {
+		t.Fatalf("SetupUL: %v", err)
+	}
+	proofs := makeProofsUL(t, p, 10)
+	proofs[3].zv[0] = Mod(new(big.Int).Add(proofs[3].zv[0], big.NewInt(1)), bn256.Order)
+
+	ok, err := BatchVerifyUL(proofs, &p)
+	if err != nil {
+		t.Fatalf("BatchVerifyUL: %v", err)
+	}
+	if ok {
+		t.Fatal("BatchVerifyUL accepted a batch containing a tampered proof")
+	}
+}
+
+// TestBatchVerifyULRejectsShortProof checks that a proof with fewer
+// elements than p.l claims fails cleanly through BatchVerifyUL instead of
+// panicking with an index-out-of-range, mirroring VerifyUL's own guard
+// against the same short-proof shape.
+func TestBatchVerifyULRejectsShortProof(t *testing.T) {
+	p, err := SetupUL(2, 8)
+	if err != nil {
+		t.Fatalf("SetupUL: %v", err)
+	}
+	proofs := makeProofsUL(t, p, 10)
+	proofs[3].V = proofs[3].V[:len(proofs[3].V)-1]
+
+	ok, err := BatchVerifyUL(proofs, &p)
+	if err == nil {
+		t.Fatal("BatchVerifyUL accepted a batch containing a proof shorter than p.l")
+	}
+	if ok {
+		t.Fatal("BatchVerifyUL reported success for a batch containing a short proof")
+	}
+}
+
+func TestBatchVerifyULRejectsEmptyBatch(t *testing.T) {
+	p, err := SetupUL(2, 8)
+	if err != nil {
+		t.Fatalf("SetupUL: %v", err)
+	}
+	if _, err := BatchVerifyUL(nil, &p); err == nil {
+		t.Fatal("BatchVerifyUL accepted an empty batch")
+	}
+}
+
+// BenchmarkVerifyUL compares sequential per-proof VerifyUL calls against
+// BatchVerifyUL's aggregated per-slot check at several batch sizes, to
+// show how one BatchVerifyUL call scales against n one-at-a-time
+// verifications.
+func BenchmarkVerifyUL(b *testing.B) {
+	p, err := SetupUL(2, 8)
+	if err != nil {
+		b.Fatalf("SetupUL: %v", err)
+	}
+
+	for _, n := range []int{1, 10, 100} {
+		proofs := makeProofsUL(b, p, n)
+
+		b.Run(fmt.Sprintf("sequential/n=%d", n), func(b *testing.B) {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, proof := range proofs {
+					ok, err := VerifyUL(proof, &p)
+					if err != nil {
+						b.Fatalf("VerifyUL: %v", err)
+					}
+					if !ok {
+						b.Fatal("VerifyUL rejected a valid proof")
+					}
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("batch/n=%d", n), func(b *testing.B) {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ok, err := BatchVerifyUL(proofs, &p)
+				if err != nil {
+					b.Fatalf("BatchVerifyUL: %v", err)
+				}
+				if !ok {
+					b.Fatal("BatchVerifyUL rejected a valid batch")
+				}
+			}
+		})
+	}
+}
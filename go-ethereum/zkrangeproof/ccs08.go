@@ -28,7 +28,6 @@ import (
 	"strconv"
 	"bytes"
 	"math/big"
-	"math"
 	"crypto/rand"
 	"github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256"
 )
@@ -110,11 +109,14 @@ func SetupSet(s []int64) (paramsSet, error) {
 	p.signatures = make(map[int64]*bn256.G2)
 	for i=0; i < len(s); i++ {
 		sig_i, _ := sign(new(big.Int).SetInt64(int64(s[i])), p.kp.privk)
-		p.signatures[s[i]] = sig_i 
+		p.signatures[s[i]] = sig_i
 	}
 	//TODO: protect the 'master' key
-	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
-	p.H = new(bn256.G2).ScalarBaseMult(h)
+	H, err := hashToG2([]byte("zkrangeproof/ccs08/H/set"))
+	if err != nil {
+		return p, err
+	}
+	p.H = H
 	return p, nil
 }
 
@@ -133,11 +135,14 @@ func SetupUL(u, l int64) (paramsUL, error) {
 	p.signatures = make(map[string]*bn256.G2)
 	for i=0; i < u; i++ {
 		sig_i, _ := sign(new(big.Int).SetInt64(i), p.kp.privk)
-		p.signatures[strconv.FormatInt(i, 10)] = sig_i 
+		p.signatures[strconv.FormatInt(i, 10)] = sig_i
 	}
 	//TODO: protect the 'master' key
-	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
-	p.H = new(bn256.G2).ScalarBaseMult(h)
+	H, err := hashToG2([]byte("zkrangeproof/ccs08/H"))
+	if err != nil {
+		return p, err
+	}
+	p.H = H
 	p.u = u
 	p.l = l
 	return p, nil
@@ -293,6 +298,15 @@ func VerifySet(proof_out *proofSet, p *paramsSet) (bool, error) {
 VerifyUL is used to validate the ZKRP proof. It returns true iff the proof is valid.
 */
 func VerifyUL(proof_out *proofUL, p *paramsUL) (bool, error) {
+	// proof_out's slices are sized by whatever l the prover (or, via the
+	// precompile, an attacker) put on the wire, while the loops below index
+	// every one of them up to p.l; a proof with fewer elements than p.l
+	// claims would otherwise panic here instead of just failing to verify.
+	if int64(len(proof_out.V)) != p.l || int64(len(proof_out.a)) != p.l ||
+		int64(len(proof_out.s)) != p.l || int64(len(proof_out.t)) != p.l ||
+		int64(len(proof_out.zsig)) != p.l || int64(len(proof_out.zv)) != p.l {
+		return false, errors.New("zkrangeproof: proofUL does not have p.l elements")
+	}
 	var (
 		i int64
 		D *bn256.G2
@@ -356,44 +370,51 @@ type ccs08 struct {
 }
 
 /*
-Setup receives integers a and b, and configures the parameters for the rangeproof scheme.
+Setup receives integers a and b, and configures the parameters for the rangeproof scheme,
+using DefaultCost to pick (u,l).
 */
 func (zkrp *ccs08) Setup(a,b int64) (error) {
-	// Compute optimal values for u and l
-	var (
-		u,l int64
-		logb float64
-		p *params
-	)
+	return zkrp.SetupWithCost(a, b, DefaultCost)
+}
+
+/*
+SetupWithOptions behaves like Setup, but lets the caller trade off setup
+size against prover/verifier time via SetupOptions instead of accepting
+DefaultCost. A nil opts, or a nil opts.Cost, falls back to DefaultCost.
+*/
+func (zkrp *ccs08) SetupWithOptions(a, b int64, opts *SetupOptions) error {
+	var cost func(u, l int64) float64
+	if opts != nil {
+		cost = opts.Cost
+	}
+	return zkrp.SetupWithCost(a, b, cost)
+}
+
+/*
+SetupWithCost behaves like Setup, but lets the caller trade off setup size
+against prover/verifier time by supplying its own cost function directly;
+see SetupWithOptions and optimalUL for how (u,l) is chosen. A nil cost
+falls back to DefaultCost.
+*/
+func (zkrp *ccs08) SetupWithCost(a, b int64, cost func(u, l int64) float64) (error) {
+	var p *params
 	if a > b {
 		zkrp.p = nil
 		return errors.New("a must be less than or equal to b")
 	}
-	p = new(params)
-	logb = math.Log(float64(b))
-	if logb != 0 {
-		// TODO: understand how to find optimal parameters
-		//u = b / int64(logb)
-		u = 100
-		if u != 0 {
-			l = 0
-			for i:=b; i>0; i=i/u {
-				l=l+1
-			}
-			params_out, e := SetupUL(u, l)
-			p.p = &params_out
-			p.a = a
-			p.b = b
-			zkrp.p = p
-			return e
-		} else {
-			zkrp.p = nil
-			return errors.New("u is zero") 
-		}
-	} else {
+	w := b - a + 1
+	if w <= 0 {
 		zkrp.p = nil
-		return errors.New("log(b) is zero") 
+		return errors.New("range width must be positive")
 	}
+	p = new(params)
+	u, l := optimalUL(w, cost)
+	params_out, e := setupULCached(u, l)
+	p.p = params_out
+	p.a = a
+	p.b = b
+	zkrp.p = p
+	return e
 }
 
 /*
@@ -0,0 +1,384 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file implements a distributed variant of SetupUL, so that the BB
+signature private key used by the trusted setup is never held by a single
+party. It follows the Pedersen/Feldman verifiable secret sharing scheme:
+each party commits to a degree t-1 polynomial and sends shares of it to the
+other parties over Party.Transport, and the parties that pass verification
+combine their shares into a (t,n)-threshold key. Signing mirrors the same
+shape: a fresh blinding value is shared the same way x was, and each active
+party folds its own x_i and b_i locally, sending only the Lagrange-weighted
+products b_i and (x_i+m)*b_i over Transport to the combiner -- never x_i
+itself. SetupULDistributed drives all n parties from one goroutine for ease
+of testing, but every private value crosses between parties only via
+Transport.Send/Receive, and a party's share never leaves its own Party
+value: swap in a real network Transport and the same code runs as n
+separate processes with none of them ever seeing another party's secret.
+*/
+package zkrangeproof
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strconv"
+
+	"github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256"
+	"github.com/ing-bank/zkrangeproof/go-ethereum/zkrangeproof/net"
+)
+
+// Party identifies one of the n participants in the distributed setup.
+// share is populated by SetupULDistributed and is never copied out to a
+// bulk collection the caller can hold onto; use Share to read a party's
+// own value back (e.g. to persist it to that party's own storage).
+type Party struct {
+	ID        int64
+	Transport net.Transport
+
+	share *KeyShare
+}
+
+// Share returns the key share SetupULDistributed generated for this party,
+// or nil if it hasn't run yet. It is meant to be called by the party that
+// owns p, not collected across parties by a third party.
+func (p *Party) Share() *KeyShare {
+	return p.share
+}
+
+/*
+KeyShare is a single party's share x_j of the threshold BB signing key,
+together with the public commitment needed by other parties to verify it.
+*/
+type KeyShare struct {
+	Index int64
+	Share *big.Int
+	Y     *bn256.G1
+}
+
+// Marshal encodes a KeyShare as index || share || Y, with the share as a
+// fixed 32-byte big-endian mod-order value and Y using its native Marshal.
+func (ks *KeyShare) Marshal() []byte {
+	out := make([]byte, 8)
+	big.NewInt(ks.Index).FillBytes(out)
+	shareBytes := make([]byte, 32)
+	ks.Share.FillBytes(shareBytes)
+	out = append(out, shareBytes...)
+	out = append(out, ks.Y.Marshal()...)
+	return out
+}
+
+// Unmarshal decodes a KeyShare previously produced by Marshal.
+func (ks *KeyShare) Unmarshal(m []byte) error {
+	if len(m) < 8+32 {
+		return errors.New("zkrangeproof: invalid KeyShare encoding")
+	}
+	ks.Index = new(big.Int).SetBytes(m[:8]).Int64()
+	ks.Share = new(big.Int).SetBytes(m[8:40])
+	ks.Y = new(bn256.G1)
+	_, err := ks.Y.Unmarshal(m[40:])
+	return err
+}
+
+// polynomial represents f_i(x) = a_0 + a_1*x + ... + a_{t-1}*x^{t-1} mod bn256.Order.
+type polynomial struct {
+	coeffs []*big.Int
+}
+
+func newPolynomial(t int) (polynomial, error) {
+	coeffs := make([]*big.Int, t)
+	for i := range coeffs {
+		c, err := rand.Int(rand.Reader, bn256.Order)
+		if err != nil {
+			return polynomial{}, err
+		}
+		coeffs[i] = c
+	}
+	return polynomial{coeffs: coeffs}, nil
+}
+
+func (p polynomial) eval(x int64) *big.Int {
+	xi := new(big.Int).SetInt64(x)
+	acc := new(big.Int).Set(p.coeffs[0])
+	xPow := new(big.Int).SetInt64(1)
+	for i := 1; i < len(p.coeffs); i++ {
+		xPow = Mod(Multiply(xPow, xi), bn256.Order)
+		acc = Mod(new(big.Int).Add(acc, Multiply(p.coeffs[i], xPow)), bn256.Order)
+	}
+	return acc
+}
+
+// commitments returns C_k = g^{a_k} for each coefficient of the polynomial,
+// which lets any recipient Feldman-verify a share without learning a_k.
+func (p polynomial) commitments() []*bn256.G1 {
+	c := make([]*bn256.G1, len(p.coeffs))
+	for i, a := range p.coeffs {
+		c[i] = new(bn256.G1).ScalarBaseMult(a)
+	}
+	return c
+}
+
+// verifyShare checks f_i(j) against the broadcast commitments C_{i,k}:
+// g^{f_i(j)} == prod_k C_{i,k}^{j^k}.
+func verifyShare(share *big.Int, j int64, commitments []*bn256.G1) bool {
+	lhs := new(bn256.G1).ScalarBaseMult(share)
+	jBig := new(big.Int).SetInt64(j)
+	jPow := new(big.Int).SetInt64(1)
+	rhs := new(bn256.G1).ScalarBaseMult(new(big.Int))
+	for _, ck := range commitments {
+		rhs.Add(rhs, new(bn256.G1).ScalarMult(ck, jPow))
+		jPow = Mod(Multiply(jPow, jBig), bn256.Order)
+	}
+	return Equal(lhs, rhs)
+}
+
+// Equal reports whether two G1 points encode to the same bytes.
+func Equal(a, b *bn256.G1) bool {
+	return string(a.Marshal()) == string(b.Marshal())
+}
+
+/*
+distributeShares runs one round of additive Pedersen/Feldman secret
+sharing among parties: each party contributes its own random degree t-1
+polynomial, evaluates it for every other party and sends the evaluation
+over Transport, and every party sums what it receives into its own final
+share. Both the long-term key (SetupULDistributed) and the per-signature
+blinding value (signDistributed) are generated by this same exchange, so
+neither duplicates it. The returned shares slice is index-aligned with
+parties and, like any intermediate sharing material, must not be handed in
+bulk to anyone outside this function.
+*/
+func distributeShares(parties []Party, t int) ([]*big.Int, *bn256.G1, error) {
+	n := len(parties)
+	commitments := make([][]*bn256.G1, n)
+	for i := range parties {
+		poly, err := newPolynomial(t)
+		if err != nil {
+			return nil, nil, err
+		}
+		commitments[i] = poly.commitments()
+		for j := range parties {
+			fij := poly.eval(parties[j].ID)
+			shareBytes := make([]byte, 32)
+			fij.FillBytes(shareBytes)
+			if err := parties[i].Transport.Send(parties[j].ID, shareBytes); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// Each party j privately receives f_i(j) from every party i over its own
+	// Transport and verifies it against the broadcast commitments, raising a
+	// complaint (here, an error) if it does not match.
+	shares := make([]*big.Int, n)
+	for j := range parties {
+		x := new(big.Int)
+		for i := range parties {
+			shareBytes, err := parties[j].Transport.Receive(parties[i].ID)
+			if err != nil {
+				return nil, nil, err
+			}
+			fij := new(big.Int).SetBytes(shareBytes)
+			if !verifyShare(fij, parties[j].ID, commitments[i]) {
+				return nil, nil, errors.New("zkrangeproof: complaint, invalid share from party " + strconv.Itoa(i))
+			}
+			x = Mod(new(big.Int).Add(x, fij), bn256.Order)
+		}
+		shares[j] = x
+	}
+
+	// Y = prod_i g^{a_{i,0}} is the aggregated public value, reconstructible
+	// from the first commitment of every party without combining shares.
+	y := new(bn256.G1).ScalarBaseMult(new(big.Int))
+	for i := range parties {
+		y.Add(y, commitments[i][0])
+	}
+	return shares, y, nil
+}
+
+/*
+SetupULDistributed runs a Pedersen/Feldman (t,n)-threshold DKG among parties
+and then has them jointly sign every element of [0,u) to produce a paramsUL
+that VerifyUL accepts exactly as it would a centrally-generated one, without
+any single party ever learning the full private key. n must be at least
+2t-1: signing reconstructs a degree 2(t-1) polynomial, which takes one more
+pair of shares than reconstructing x itself does. Each party's own share is
+attached to its Party value (retrievable via Party.Share) and is never
+otherwise returned, so the caller driving this function never ends up
+holding a usable bundle of shares.
+*/
+func SetupULDistributed(u, l int64, parties []Party, t int) (paramsUL, error) {
+	var p paramsUL
+	n := len(parties)
+	if t <= 0 || t > n {
+		return p, errors.New("zkrangeproof: threshold must be in [1, n]")
+	}
+	if 2*t-1 > n {
+		return p, errors.New("zkrangeproof: SignDistributed needs 2t-1 parties, increase n or lower t")
+	}
+
+	shares, y, err := distributeShares(parties, t)
+	if err != nil {
+		return p, err
+	}
+	for j := range parties {
+		parties[j].share = &KeyShare{Index: parties[j].ID, Share: shares[j], Y: y}
+	}
+
+	// The signature table is signed jointly under the shared key by the
+	// first 2t-1 parties via signDistributed; H is public and derived with
+	// hashToG2 as in SetupUL, under its own domain separator.
+	signers := parties[:2*t-1]
+	p.signatures = make(map[string]*bn256.G2)
+	for i := int64(0); i < u; i++ {
+		sig, err := signDistributed(signers, t, new(big.Int).SetInt64(i))
+		if err != nil {
+			return p, err
+		}
+		p.signatures[strconv.FormatInt(i, 10)] = sig
+	}
+	H, err := hashToG2([]byte("zkrangeproof/dkg/H"))
+	if err != nil {
+		return p, err
+	}
+	p.H = H
+	p.u = u
+	p.l = l
+	p.kp = keypair{pubk: y, privk: nil}
+	return p, nil
+}
+
+/*
+signDistributed has the parties in active (exactly 2t-1 of them) jointly
+produce the BB signature sig(m) = g2^{1/(x+m)} without any party other than
+active[0] -- acting as the combiner for this one signature -- ever seeing
+more than a single party's blinded contribution at a time, and without any
+party other than its owner ever touching an x_i.
+
+x is held as the degree t-1 polynomial f with f(0) = x and active[i].share
+= f(active[i].Index). To invert (x+m) jointly, the parties also need a
+random b shared the same way -- b is a single secret, not an independent
+draw per party, because computing (x+m)*b locally as (x_i+m)*b_i only
+reconstructs (x+m)*b correctly if the pointwise products themselves lie on
+a single low-degree polynomial. That product polynomial h = f'*g (where
+f' = f+m) has degree 2(t-1), so reconstructing h(0) by Lagrange
+interpolation at 0 needs 2t-1 points, not t: this is the standard trick for
+multiplying two Shamir-shared values without an extra communication round.
+b is (re-)shared fresh for every signature via distributeShares, exactly
+like x was, so no party other than its owner ever learns a b_i either.
+
+Each active party i!=0 computes its own Lagrange-weighted contribution
+pair (lambda_i*b_i, lambda_i*(x_i+m)*b_i) entirely from values only it
+holds and sends the pair, never b_i or x_i+m alone, to the combiner over
+Transport; active[0] folds its own contribution in directly and sums what
+it receives into running totals for b and h(0), so it only ever has the
+aggregates in hand, not any other party's b_i or x_i. Once h(0) = (x+m)*b
+and b itself are both reconstructed, 1/(x+m) = b / h(0).
+*/
+func signDistributed(active []Party, t int, m *big.Int) (*bn256.G2, error) {
+	need := 2*t - 1
+	if t <= 0 || len(active) != need {
+		return nil, errors.New("zkrangeproof: signing needs exactly 2t-1 active parties")
+	}
+
+	bShares, _, err := distributeShares(active, t)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(active))
+	for i := range active {
+		ids[i] = active[i].ID
+	}
+	mu := lagrangeCoefficients(ids)
+
+	contribution := func(i int) (bTerm, hTerm *big.Int) {
+		fprime := Mod(new(big.Int).Add(active[i].share.Share, m), bn256.Order)
+		hi := Multiply(fprime, bShares[i])
+		bTerm = Mod(Multiply(mu[i], bShares[i]), bn256.Order)
+		hTerm = Mod(Multiply(mu[i], hi), bn256.Order)
+		return bTerm, hTerm
+	}
+
+	combiner := active[0]
+	b, h0 := contribution(0)
+	for i := 1; i < len(active); i++ {
+		bTerm, hTerm := contribution(i)
+		payload := marshalScalarPair(bTerm, hTerm)
+		if err := active[i].Transport.Send(combiner.ID, payload); err != nil {
+			return nil, err
+		}
+	}
+	for i := 1; i < len(active); i++ {
+		payload, err := combiner.Transport.Receive(active[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		bTerm, hTerm, err := unmarshalScalarPair(payload)
+		if err != nil {
+			return nil, err
+		}
+		b = Mod(new(big.Int).Add(b, bTerm), bn256.Order)
+		h0 = Mod(new(big.Int).Add(h0, hTerm), bn256.Order)
+	}
+
+	h0Inv := new(big.Int).ModInverse(h0, bn256.Order)
+	if h0Inv == nil {
+		return nil, errors.New("zkrangeproof: x+m is not invertible mod order")
+	}
+	inv := Mod(Multiply(b, h0Inv), bn256.Order)
+	return new(bn256.G2).ScalarBaseMult(inv), nil
+}
+
+// marshalScalarPair/unmarshalScalarPair encode the two mod-order scalars a
+// signing contribution carries over Transport as fixed 32-byte big-endian
+// blocks.
+func marshalScalarPair(a, b *big.Int) []byte {
+	out := make([]byte, 64)
+	a.FillBytes(out[:32])
+	b.FillBytes(out[32:])
+	return out
+}
+
+func unmarshalScalarPair(m []byte) (*big.Int, *big.Int, error) {
+	if len(m) != 64 {
+		return nil, nil, errors.New("zkrangeproof: invalid signing contribution encoding")
+	}
+	return new(big.Int).SetBytes(m[:32]), new(big.Int).SetBytes(m[32:]), nil
+}
+
+// lagrangeCoefficients returns the Lagrange basis coefficients for
+// interpolating, at x=0, the polynomial whose values at indices are known.
+func lagrangeCoefficients(indices []int64) []*big.Int {
+	lambdas := make([]*big.Int, len(indices))
+	for i := range indices {
+		num := big.NewInt(1)
+		den := big.NewInt(1)
+		xi := new(big.Int).SetInt64(indices[i])
+		for j := range indices {
+			if i == j {
+				continue
+			}
+			xj := new(big.Int).SetInt64(indices[j])
+			num = Mod(Multiply(num, xj), bn256.Order)
+			den = Mod(Multiply(den, Mod(new(big.Int).Sub(xj, xi), bn256.Order)), bn256.Order)
+		}
+		denInv := new(big.Int).ModInverse(den, bn256.Order)
+		lambdas[i] = Mod(Multiply(num, denInv), bn256.Order)
+	}
+	return lambdas
+}
@@ -0,0 +1,148 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkrangeproof
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256"
+	"github.com/ing-bank/zkrangeproof/go-ethereum/zkrangeproof/net"
+)
+
+/*
+memTransport is a toy net.Transport backing dkg_test.go's own single
+goroutine: every Send for a (from,to) pair queues a message that the
+matching Receive later dequeues, via a router shared by every party. It
+exists only to back the claim in dkg.go's package doc comment that
+SetupULDistributed can be driven end to end from one goroutine; it is not
+meant to model a real network (there is no blocking, ordering guarantee
+beyond FIFO per pair, or authentication).
+*/
+var _ net.Transport = memTransport{}
+
+type memRouter struct {
+	inbox map[[2]int64][][]byte
+}
+
+func newMemRouter() *memRouter {
+	return &memRouter{inbox: make(map[[2]int64][][]byte)}
+}
+
+type memTransport struct {
+	id     int64
+	router *memRouter
+}
+
+func (tr memTransport) Send(to int64, msg []byte) error {
+	key := [2]int64{tr.id, to}
+	tr.router.inbox[key] = append(tr.router.inbox[key], msg)
+	return nil
+}
+
+func (tr memTransport) Receive(from int64) ([]byte, error) {
+	key := [2]int64{from, tr.id}
+	q := tr.router.inbox[key]
+	if len(q) == 0 {
+		return nil, errors.New("zkrangeproof: no message queued from that party")
+	}
+	tr.router.inbox[key] = q[1:]
+	return q[0], nil
+}
+
+// newMemParties wires up n parties with IDs 1..n sharing one memRouter, so
+// Party.Transport.Send/Receive between any pair of them actually delivers.
+func newMemParties(n int) []Party {
+	router := newMemRouter()
+	parties := make([]Party, n)
+	for i := range parties {
+		id := int64(i + 1)
+		parties[i] = Party{ID: id, Transport: memTransport{id: id, router: router}}
+	}
+	return parties
+}
+
+// TestSetupULDistributedRoundTrip checks that a (t,n)-threshold DKG over a
+// toy in-memory Transport produces a paramsUL that VerifyUL accepts exactly
+// like a centrally-generated one, confirming the distributed signing path
+// (the 2t-1 Shamir-product trick in signDistributed) actually reconstructs
+// valid BB signatures and not just shares that happen to combine for x.
+func TestSetupULDistributedRoundTrip(t *testing.T) {
+	parties := newMemParties(5)
+	p, err := SetupULDistributed(2, 8, parties, 3)
+	if err != nil {
+		t.Fatalf("SetupULDistributed: %v", err)
+	}
+
+	r, err := rand.Int(rand.Reader, bn256.Order)
+	if err != nil {
+		t.Fatalf("rand.Int: %v", err)
+	}
+	proof, err := ProveUL(big.NewInt(170), r, p)
+	if err != nil {
+		t.Fatalf("ProveUL: %v", err)
+	}
+	ok, err := VerifyUL(&proof, &p)
+	if err != nil {
+		t.Fatalf("VerifyUL: %v", err)
+	}
+	if !ok {
+		t.Fatal("VerifyUL rejected a proof built from a distributed setup")
+	}
+}
+
+// TestSetupULDistributedNoPrivateKey checks that SetupULDistributed never
+// reconstructs the BB private key anywhere a caller can reach it -- the
+// entire point of running the DKG instead of keygen -- and that each
+// party's own share is only accessible through that party's own Share().
+func TestSetupULDistributedNoPrivateKey(t *testing.T) {
+	parties := newMemParties(5)
+	p, err := SetupULDistributed(2, 8, parties, 3)
+	if err != nil {
+		t.Fatalf("SetupULDistributed: %v", err)
+	}
+	if p.kp.privk != nil {
+		t.Fatal("SetupULDistributed returned a paramsUL holding the combined private key")
+	}
+
+	seen := make(map[string]bool)
+	for i := range parties {
+		share := parties[i].Share()
+		if share == nil {
+			t.Fatalf("party %d has no share after SetupULDistributed", parties[i].ID)
+		}
+		if share.Index != parties[i].ID {
+			t.Fatalf("party %d holds a share indexed for party %d", parties[i].ID, share.Index)
+		}
+		key := share.Share.String()
+		if seen[key] {
+			t.Fatalf("two parties hold the same share value %v", share.Share)
+		}
+		seen[key] = true
+	}
+}
+
+// TestSetupULDistributedRejectsInsufficientParties checks the n >= 2t-1
+// guard signDistributed's Shamir-product trick depends on.
+func TestSetupULDistributedRejectsInsufficientParties(t *testing.T) {
+	parties := newMemParties(3)
+	if _, err := SetupULDistributed(2, 8, parties, 3); err == nil {
+		t.Fatal("SetupULDistributed accepted n=3 parties for t=3, below the 2t-1 minimum")
+	}
+}
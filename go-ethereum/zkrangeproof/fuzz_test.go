@@ -0,0 +1,87 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkrangeproof
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256"
+)
+
+// seedProofULAndParams runs SetupUL/ProveUL directly (rather than reusing
+// mustProveUL, which needs a live *testing.T to report failures on) so the
+// two Fuzz entry points below can build their seed corpus without one.
+func seedProofULAndParams() (proofUL, paramsUL, error) {
+	p, err := SetupUL(2, 8)
+	if err != nil {
+		return proofUL{}, paramsUL{}, err
+	}
+	r, err := rand.Int(rand.Reader, bn256.Order)
+	if err != nil {
+		return proofUL{}, paramsUL{}, err
+	}
+	proof_out, err := ProveUL(big.NewInt(170), r, p)
+	if err != nil {
+		return proofUL{}, paramsUL{}, err
+	}
+	return proof_out, p, nil
+}
+
+// FuzzProofULUnmarshal complements TestProofULUnmarshalRejectsMalformedInput's
+// fixed truncation/adversarial-l cases with an open-ended corpus: go test
+// -fuzz (and any OSS-Fuzz corpus built from it) can mutate well beyond those
+// hand-picked inputs. UnmarshalBinary must never panic, on any input.
+func FuzzProofULUnmarshal(f *testing.F) {
+	f.Add([]byte(nil))
+	f.Add([]byte{0})
+
+	if proof_out, _, err := seedProofULAndParams(); err == nil {
+		if data, err := proof_out.MarshalBinary(); err == nil {
+			f.Add(data)
+			for n := 0; n <= len(data); n += 7 {
+				f.Add(data[:n])
+			}
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		got := new(proofUL)
+		_ = got.UnmarshalBinary(data)
+	})
+}
+
+// FuzzParamsULUnmarshal is the paramsUL counterpart of FuzzProofULUnmarshal.
+func FuzzParamsULUnmarshal(f *testing.F) {
+	f.Add([]byte(nil))
+	f.Add([]byte{0})
+
+	if _, p, err := seedProofULAndParams(); err == nil {
+		if data, err := p.MarshalBinary(); err == nil {
+			f.Add(data)
+			for n := 0; n <= len(data); n += 7 {
+				f.Add(data[:n])
+			}
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		got := new(paramsUL)
+		_ = got.UnmarshalBinary(data)
+	})
+}
@@ -0,0 +1,43 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkrangeproof
+
+import (
+	"github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256"
+	curvebn256 "github.com/ing-bank/zkrangeproof/go-ethereum/curves/bn256"
+)
+
+/*
+hashToG2 derives a concrete bn256.G2 generator with unknown discrete log
+from a domain separator, for the SetupUL/SetupSet/SetupULDistributed paths
+that still work directly in concrete bn256 types rather than through the
+pairing.Curve abstraction. It delegates to curves/bn256.Curve.HashToG2 --
+the same try-and-increment construction bulletproof.hashToG1 uses for
+G1 -- rather than reimplementing it, so there is exactly one hash-to-curve
+for this backend. Using this in place of a fixed scalar h times G2Base
+matters because a known discrete log for H lets a prover equivocate a
+Pedersen commitment C = g^x.H^r (open it as a different x' by adjusting
+r), breaking the binding property the range proof's soundness rests on.
+*/
+func hashToG2(domain []byte) (*bn256.G2, error) {
+	marshaled := curvebn256.New().HashToG2(domain).Marshal()
+	h := new(bn256.G2)
+	if _, err := h.Unmarshal(marshaled); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
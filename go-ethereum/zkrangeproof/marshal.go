@@ -0,0 +1,401 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file adds a deterministic wire encoding for proofUL, proofSet,
+paramsUL and proof, so a proof can be shipped from a prover to a remote
+verifier (or to the precompile in the sibling precompile package). The
+layout is a version byte followed by the (u,l) dimensions where relevant,
+then each bn256.G2 element via its native Marshal, each *big.Int as a fixed
+32-byte big-endian mod-order value, and each *bn256.GT element via its
+native 384-byte encoding.
+*/
+package zkrangeproof
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strconv"
+
+	"github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256"
+)
+
+const wireVersion = byte(1)
+
+const (
+	scalarSize = 32
+	g2Size     = 128
+	gtSize     = 384
+)
+
+func putScalar(buf []byte, v *big.Int) {
+	b := Mod(v, bn256.Order).Bytes()
+	copy(buf[scalarSize-len(b):], b)
+}
+
+func getScalar(buf []byte) *big.Int {
+	return new(big.Int).SetBytes(buf[:scalarSize])
+}
+
+func putUint64(buf []byte, v int64) {
+	binary.BigEndian.PutUint64(buf, uint64(v))
+}
+
+func getUint64(buf []byte) int64 {
+	return int64(binary.BigEndian.Uint64(buf))
+}
+
+// MarshalBinary encodes proof_out as: version | l | D | C | V[0..l) | a[0..l) | s,t,zsig,zv[0..l) | c | m | zr.
+func (proof_out *proofUL) MarshalBinary() ([]byte, error) {
+	l := int64(len(proof_out.V))
+	buf := make([]byte, 0, 1+8+2*g2Size+l*g2Size+l*gtSize+4*l*scalarSize+3*scalarSize)
+	buf = append(buf, wireVersion)
+
+	lBytes := make([]byte, 8)
+	putUint64(lBytes, l)
+	buf = append(buf, lBytes...)
+
+	buf = append(buf, proof_out.D.Marshal()...)
+	buf = append(buf, proof_out.C.Marshal()...)
+	for i := int64(0); i < l; i++ {
+		buf = append(buf, proof_out.V[i].Marshal()...)
+	}
+	for i := int64(0); i < l; i++ {
+		buf = append(buf, proof_out.a[i].Marshal()...)
+	}
+	for i := int64(0); i < l; i++ {
+		s := make([]byte, scalarSize)
+		putScalar(s, proof_out.s[i])
+		buf = append(buf, s...)
+		t := make([]byte, scalarSize)
+		putScalar(t, proof_out.t[i])
+		buf = append(buf, t...)
+		zsig := make([]byte, scalarSize)
+		putScalar(zsig, proof_out.zsig[i])
+		buf = append(buf, zsig...)
+		zv := make([]byte, scalarSize)
+		putScalar(zv, proof_out.zv[i])
+		buf = append(buf, zv...)
+	}
+	c := make([]byte, scalarSize)
+	putScalar(c, proof_out.c)
+	buf = append(buf, c...)
+	m := make([]byte, scalarSize)
+	putScalar(m, proof_out.m)
+	buf = append(buf, m...)
+	zr := make([]byte, scalarSize)
+	putScalar(zr, proof_out.zr)
+	buf = append(buf, zr...)
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a proofUL previously produced by MarshalBinary.
+func (proof_out *proofUL) UnmarshalBinary(data []byte) error {
+	if len(data) < 1+8 {
+		return errors.New("zkrangeproof: proofUL encoding too short")
+	}
+	if data[0] != wireVersion {
+		return errors.New("zkrangeproof: unsupported proofUL wire version")
+	}
+	pos := 1
+	l := getUint64(data[pos : pos+8])
+	pos += 8
+	// l comes straight off the wire and sizes every make() below; a
+	// negative or oversized value (e.g. crafted calldata reaching this via
+	// the precompile package) must be rejected here, before it is ever used
+	// to allocate, rather than left to panic make() with a bogus length.
+	elemSize := int64(g2Size + gtSize + 4*scalarSize)
+	if l < 0 || l > int64(len(data))/elemSize+1 {
+		return errors.New("zkrangeproof: proofUL l out of range")
+	}
+	if want := 2*int64(g2Size) + l*elemSize + 3*int64(scalarSize); want > int64(len(data)-pos) {
+		return errors.New("zkrangeproof: proofUL encoding truncated")
+	}
+
+	readG2 := func() (*bn256.G2, error) {
+		if pos+g2Size > len(data) {
+			return nil, errors.New("zkrangeproof: proofUL encoding truncated")
+		}
+		g := new(bn256.G2)
+		_, err := g.Unmarshal(data[pos : pos+g2Size])
+		pos += g2Size
+		return g, err
+	}
+	readGT := func() (*bn256.GT, error) {
+		if pos+gtSize > len(data) {
+			return nil, errors.New("zkrangeproof: proofUL encoding truncated")
+		}
+		g := new(bn256.GT)
+		_, err := g.Unmarshal(data[pos : pos+gtSize])
+		pos += gtSize
+		return g, err
+	}
+	readScalar := func() (*big.Int, error) {
+		if pos+scalarSize > len(data) {
+			return nil, errors.New("zkrangeproof: proofUL encoding truncated")
+		}
+		v := getScalar(data[pos : pos+scalarSize])
+		pos += scalarSize
+		return v, nil
+	}
+
+	var err error
+	if proof_out.D, err = readG2(); err != nil {
+		return err
+	}
+	if proof_out.C, err = readG2(); err != nil {
+		return err
+	}
+	proof_out.V = make([]*bn256.G2, l)
+	for i := int64(0); i < l; i++ {
+		if proof_out.V[i], err = readG2(); err != nil {
+			return err
+		}
+	}
+	proof_out.a = make([]*bn256.GT, l)
+	for i := int64(0); i < l; i++ {
+		if proof_out.a[i], err = readGT(); err != nil {
+			return err
+		}
+	}
+	proof_out.s = make([]*big.Int, l)
+	proof_out.t = make([]*big.Int, l)
+	proof_out.zsig = make([]*big.Int, l)
+	proof_out.zv = make([]*big.Int, l)
+	for i := int64(0); i < l; i++ {
+		if proof_out.s[i], err = readScalar(); err != nil {
+			return err
+		}
+		if proof_out.t[i], err = readScalar(); err != nil {
+			return err
+		}
+		if proof_out.zsig[i], err = readScalar(); err != nil {
+			return err
+		}
+		if proof_out.zv[i], err = readScalar(); err != nil {
+			return err
+		}
+	}
+	if proof_out.c, err = readScalar(); err != nil {
+		return err
+	}
+	if proof_out.m, err = readScalar(); err != nil {
+		return err
+	}
+	if proof_out.zr, err = readScalar(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarshalBinary encodes proof_out as: version | D | C | V | a | s,t,zsig,zv | c | m | zr.
+func (proof_out *proofSet) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 1+3*g2Size+gtSize+7*scalarSize)
+	buf = append(buf, wireVersion)
+	buf = append(buf, proof_out.D.Marshal()...)
+	buf = append(buf, proof_out.C.Marshal()...)
+	buf = append(buf, proof_out.V.Marshal()...)
+	buf = append(buf, proof_out.a.Marshal()...)
+	for _, v := range []*big.Int{proof_out.s, proof_out.t, proof_out.zsig, proof_out.zv, proof_out.c, proof_out.m, proof_out.zr} {
+		b := make([]byte, scalarSize)
+		putScalar(b, v)
+		buf = append(buf, b...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a proofSet previously produced by MarshalBinary.
+func (proof_out *proofSet) UnmarshalBinary(data []byte) error {
+	if len(data) < 1+3*g2Size+gtSize+7*scalarSize {
+		return errors.New("zkrangeproof: proofSet encoding too short")
+	}
+	if data[0] != wireVersion {
+		return errors.New("zkrangeproof: unsupported proofSet wire version")
+	}
+	pos := 1
+	var err error
+	proof_out.D = new(bn256.G2)
+	if _, err = proof_out.D.Unmarshal(data[pos : pos+g2Size]); err != nil {
+		return err
+	}
+	pos += g2Size
+	proof_out.C = new(bn256.G2)
+	if _, err = proof_out.C.Unmarshal(data[pos : pos+g2Size]); err != nil {
+		return err
+	}
+	pos += g2Size
+	proof_out.V = new(bn256.G2)
+	if _, err = proof_out.V.Unmarshal(data[pos : pos+g2Size]); err != nil {
+		return err
+	}
+	pos += g2Size
+	proof_out.a = new(bn256.GT)
+	if _, err = proof_out.a.Unmarshal(data[pos : pos+gtSize]); err != nil {
+		return err
+	}
+	pos += gtSize
+	scalars := make([]*big.Int, 7)
+	for i := range scalars {
+		scalars[i] = getScalar(data[pos : pos+scalarSize])
+		pos += scalarSize
+	}
+	proof_out.s, proof_out.t, proof_out.zsig, proof_out.zv, proof_out.c, proof_out.m, proof_out.zr =
+		scalars[0], scalars[1], scalars[2], scalars[3], scalars[4], scalars[5], scalars[6]
+	return nil
+}
+
+// MarshalBinary encodes p as: version | u | l | H | pubk | signatures[0..u) in index order.
+func (p *paramsUL) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 1+16+g2Size+64+int(p.u)*g2Size)
+	buf = append(buf, wireVersion)
+	uBytes := make([]byte, 8)
+	putUint64(uBytes, p.u)
+	buf = append(buf, uBytes...)
+	lBytes := make([]byte, 8)
+	putUint64(lBytes, p.l)
+	buf = append(buf, lBytes...)
+	buf = append(buf, p.H.Marshal()...)
+	buf = append(buf, p.kp.pubk.Marshal()...)
+	for i := int64(0); i < p.u; i++ {
+		sig, ok := p.signatures[strconv.FormatInt(i, 10)]
+		if !ok {
+			return nil, errors.New("zkrangeproof: paramsUL missing signature for " + strconv.FormatInt(i, 10))
+		}
+		buf = append(buf, sig.Marshal()...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a paramsUL previously produced by MarshalBinary. The
+// private signing key is never part of the wire format, since paramsUL is
+// meant to be shipped to verifiers, not provers running the trusted setup.
+func (p *paramsUL) UnmarshalBinary(data []byte) error {
+	if len(data) < 1+16+g2Size {
+		return errors.New("zkrangeproof: paramsUL encoding too short")
+	}
+	if data[0] != wireVersion {
+		return errors.New("zkrangeproof: unsupported paramsUL wire version")
+	}
+	pos := 1
+	p.u = getUint64(data[pos : pos+8])
+	pos += 8
+	p.l = getUint64(data[pos : pos+8])
+	pos += 8
+	// p.l bounds the loops in VerifyUL and the multiplier in gasFor; optimalUL
+	// never returns an l above maxParamsULL (it scans l in [1,64]), so a
+	// wire value outside that range can only be forged calldata.
+	if p.l < 0 || p.l > maxParamsULL {
+		return errors.New("zkrangeproof: paramsUL l out of range")
+	}
+	p.H = new(bn256.G2)
+	var err error
+	if _, err = p.H.Unmarshal(data[pos : pos+g2Size]); err != nil {
+		return err
+	}
+	pos += g2Size
+	pubkSize := g1Size()
+	if pos+pubkSize > len(data) {
+		return errors.New("zkrangeproof: paramsUL encoding truncated")
+	}
+	pubk := new(bn256.G1)
+	if _, err = pubk.Unmarshal(data[pos : pos+pubkSize]); err != nil {
+		return err
+	}
+	pos += pubkSize
+	p.kp = keypair{pubk: pubk}
+	// p.u sizes the map hint below and bounds the read loop; reject a
+	// negative or wire-implausible value before trusting it with either.
+	if p.u < 0 || p.u > int64(len(data)-pos)/int64(g2Size)+1 {
+		return errors.New("zkrangeproof: paramsUL u out of range")
+	}
+	p.signatures = make(map[string]*bn256.G2, p.u)
+	for i := int64(0); i < p.u; i++ {
+		if pos+g2Size > len(data) {
+			return errors.New("zkrangeproof: paramsUL encoding truncated")
+		}
+		sig := new(bn256.G2)
+		if _, err = sig.Unmarshal(data[pos : pos+g2Size]); err != nil {
+			return err
+		}
+		pos += g2Size
+		p.signatures[strconv.FormatInt(i, 10)] = sig
+	}
+	return nil
+}
+
+// g1Size is the marshaled size of a bn256.G1 point, determined by probing
+// the base point once since bn256 does not export the constant.
+func g1Size() int {
+	probe := new(bn256.G1).ScalarBaseMult(new(big.Int).SetInt64(1))
+	return len(probe.Marshal())
+}
+
+// MarshalBinary encodes the composite proof (p1, p2) used by ccs08.
+func (proof_out *proof) MarshalBinary() ([]byte, error) {
+	p1, err := proof_out.p1.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	p2, err := proof_out.p2.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	lenBuf := make([]byte, 8)
+	putUint64(lenBuf, int64(len(p1)))
+	buf := append(lenBuf, p1...)
+	buf = append(buf, p2...)
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a composite proof previously produced by MarshalBinary.
+func (proof_out *proof) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("zkrangeproof: proof encoding too short")
+	}
+	p1Len := getUint64(data[:8])
+	rest := data[8:]
+	if p1Len < 0 || int64(len(rest)) < p1Len {
+		return errors.New("zkrangeproof: proof encoding truncated")
+	}
+	if err := proof_out.p1.UnmarshalBinary(rest[:p1Len]); err != nil {
+		return err
+	}
+	return proof_out.p2.UnmarshalBinary(rest[p1Len:])
+}
+
+// UnmarshalProofUL decodes a proofUL from data, for callers outside this
+// package (e.g. the precompile package) that receive proofs as raw bytes
+// and so cannot spell out the unexported proofUL type themselves.
+func UnmarshalProofUL(data []byte) (*proofUL, error) {
+	p := new(proofUL)
+	if err := p.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// UnmarshalParamsUL decodes a paramsUL from data; see UnmarshalProofUL.
+func UnmarshalParamsUL(data []byte) (*paramsUL, error) {
+	p := new(paramsUL)
+	if err := p.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// L returns the number of bits (and therefore pairings) a proof against p costs to verify.
+func (p *paramsUL) L() int64 { return p.l }
@@ -0,0 +1,160 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkrangeproof
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/ing-bank/zkrangeproof/go-ethereum/crypto/bn256"
+)
+
+func mustProveUL(t *testing.T) (proofUL, paramsUL) {
+	t.Helper()
+	p, err := SetupUL(2, 8)
+	if err != nil {
+		t.Fatalf("SetupUL: %v", err)
+	}
+	r, err := rand.Int(rand.Reader, bn256.Order)
+	if err != nil {
+		t.Fatalf("rand.Int: %v", err)
+	}
+	proof_out, err := ProveUL(big.NewInt(170), r, p)
+	if err != nil {
+		t.Fatalf("ProveUL: %v", err)
+	}
+	return proof_out, p
+}
+
+func TestProofULRoundTrip(t *testing.T) {
+	proof_out, p := mustProveUL(t)
+
+	data, err := proof_out.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	got, err := UnmarshalProofUL(data)
+	if err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	ok, err := VerifyUL(got, &p)
+	if err != nil {
+		t.Fatalf("VerifyUL: %v", err)
+	}
+	if !ok {
+		t.Fatal("VerifyUL rejected a round-tripped proof")
+	}
+}
+
+func TestParamsULRoundTrip(t *testing.T) {
+	_, p := mustProveUL(t)
+
+	data, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	got, err := UnmarshalParamsUL(data)
+	if err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	if got.u != p.u || got.l != p.l {
+		t.Fatalf("u,l mismatch: got (%d,%d), want (%d,%d)", got.u, got.l, p.u, p.l)
+	}
+}
+
+// TestProofULUnmarshalRejectsMalformedInput feeds UnmarshalBinary every
+// truncation of a valid encoding, plus a handful of adversarial l values
+// spliced into the length field, and requires either a clean error or a
+// successful decode -- never a panic. This is the regression test for the
+// wire-length-read-before-bounds-check bug: a crafted l used to reach
+// make([]*bn256.G2, l) before data was confirmed long enough to hold it,
+// which a malicious caller of the precompile package could use to crash the
+// process with a single call.
+func TestProofULUnmarshalRejectsMalformedInput(t *testing.T) {
+	proof_out, _ := mustProveUL(t)
+	data, err := proof_out.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+
+	for n := 0; n <= len(data); n++ {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("UnmarshalBinary panicked on %d-byte prefix: %v", n, r)
+				}
+			}()
+			got := new(proofUL)
+			_ = got.UnmarshalBinary(data[:n])
+		}()
+	}
+
+	adversarialL := []int64{-1, -(1 << 62), 1 << 40, 1 << 62}
+	for _, l := range adversarialL {
+		corrupted := append([]byte(nil), data...)
+		putUint64(corrupted[1:9], l)
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("UnmarshalBinary panicked on l=%d: %v", l, r)
+				}
+			}()
+			got := new(proofUL)
+			if err := got.UnmarshalBinary(corrupted); err == nil {
+				t.Fatalf("UnmarshalBinary accepted adversarial l=%d", l)
+			}
+		}()
+	}
+}
+
+func TestParamsULUnmarshalRejectsMalformedInput(t *testing.T) {
+	_, p := mustProveUL(t)
+	data, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+
+	for n := 0; n <= len(data); n++ {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("UnmarshalBinary panicked on %d-byte prefix: %v", n, r)
+				}
+			}()
+			got := new(paramsUL)
+			_ = got.UnmarshalBinary(data[:n])
+		}()
+	}
+
+	adversarialU := []int64{-1, -(1 << 62), 1 << 40, 1 << 62}
+	for _, u := range adversarialU {
+		corrupted := append([]byte(nil), data...)
+		putUint64(corrupted[1:9], u)
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("UnmarshalBinary panicked on u=%d: %v", u, r)
+				}
+			}()
+			got := new(paramsUL)
+			if err := got.UnmarshalBinary(corrupted); err == nil {
+				t.Fatalf("UnmarshalBinary accepted adversarial u=%d", u)
+			}
+		}()
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package net defines a minimal transport abstraction used by the
+distributed key generation protocol to exchange shares and commitments
+between parties. It intentionally says nothing about wire format or
+delivery guarantees, so the DKG logic can be tested with an in-memory
+transport and run for real over TCP, gRPC, or any other medium.
+*/
+package net
+
+// Transport sends and receives opaque, already-serialized messages between
+// two parties identified by ID. Implementations are responsible for
+// authenticating and ordering delivery as the deployment requires.
+type Transport interface {
+	// Send delivers msg to the party identified by to.
+	Send(to int64, msg []byte) error
+	// Receive blocks until a message from the party identified by from is
+	// available and returns it.
+	Receive(from int64) ([]byte, error)
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file replaces ccs08.Setup's hardcoded u=100 with a real search for
+(u,l): given the range width w, it looks for the u in [2,w] minimizing a
+cost function subject to u^l >= w, defaulting to DefaultCost. It also caches SetupUL results keyed by (u,l), since two
+Setup calls that land on the same parameters have no reason to regenerate
+the u signatures from scratch.
+*/
+package zkrangeproof
+
+import (
+	"math"
+	"sync"
+)
+
+/*
+SetupOptions lets a caller describe how it wants to trade off public
+parameter size against prover/verifier time, instead of accepting the
+default cost function baked into Setup.
+*/
+type SetupOptions struct {
+	// Cost scores a candidate (u,l); lower is better. If nil, DefaultCost is used.
+	Cost func(u, l int64) float64
+}
+
+/*
+DefaultCost approximates the three costs mentioned in the ccs08 paper:
+prover pairings (2*l), verifier pairings (3*l, the more expensive side to
+optimize for since verification typically happens far more often than
+proving) and public parameter size (~256*u bits).
+*/
+func DefaultCost(u, l int64) float64 {
+	const (
+		proverPairingWeight   = 1.0
+		verifierPairingWeight = 1.0
+		paramSizeWeight       = 1.0 / 256.0
+	)
+	return proverPairingWeight*float64(2*l) +
+		verifierPairingWeight*float64(3*l) +
+		paramSizeWeight*float64(u)
+}
+
+// maxParamsULL is the largest l optimalUL (and therefore Setup) ever
+// produces -- 64 bits is the most l can usefully be for a 64-bit w -- so
+// marshal.go's paramsUL.UnmarshalBinary uses it as the wire-format ceiling
+// for a value that otherwise sizes VerifyUL's loops directly.
+const maxParamsULL = 64
+
+/*
+optimalUL searches for the (u,l) minimizing cost subject to u^l >= w. For
+any l, the smallest admissible u is ceil(w^(1/l)), so rather than scanning
+every u in [2,w] (infeasible once w approaches 2^64), this scans l from 1
+up to maxParamsULL -- the most l can ever usefully be for a 64-bit w -- and
+evaluates cost at each l's minimal u. cost defaults to DefaultCost, whose
+minimum lies at a far smaller u than w/ln(w): for w = 2^32 it is
+(u,l) = (256,4).
+*/
+func optimalUL(w int64, cost func(u, l int64) float64) (int64, int64) {
+	if cost == nil {
+		cost = DefaultCost
+	}
+	if w < 2 {
+		return 2, 1
+	}
+
+	var bestU, bestL int64
+	var bestCost float64
+	for l := int64(1); l <= maxParamsULL; l++ {
+		u := minUForL(w, l)
+		if u < 2 {
+			u = 2
+		}
+		c := cost(u, l)
+		if bestL == 0 || c < bestCost {
+			bestCost = c
+			bestU, bestL = u, l
+		}
+		if u <= 2 {
+			// u cannot shrink further for larger l, so every subsequent
+			// iteration can only add verifier/prover pairing cost.
+			break
+		}
+	}
+	return bestU, bestL
+}
+
+// minUForL returns the smallest u such that u^l >= w, i.e. ceil(w^(1/l)),
+// found via float64 math.Pow with a +/-1 integer correction to guard
+// against floating point rounding.
+func minUForL(w, l int64) int64 {
+	// For l = 1 the answer is w itself; float64(w) can round up past
+	// math.MaxInt64 and overflow the int64 conversion below.
+	if l == 1 {
+		return w
+	}
+	u := int64(math.Ceil(math.Pow(float64(w), 1/float64(l))))
+	for u > 1 && pow(u-1, l) >= w {
+		u--
+	}
+	for pow(u, l) < w {
+		u++
+	}
+	return u
+}
+
+// pow returns u^l, saturating at math.MaxInt64 instead of overflowing, since
+// minUForL only needs to compare it against w.
+func pow(u, l int64) int64 {
+	result := int64(1)
+	for i := int64(0); i < l; i++ {
+		if result > math.MaxInt64/u {
+			return math.MaxInt64
+		}
+		result *= u
+	}
+	return result
+}
+
+var (
+	paramsCacheMu sync.Mutex
+	paramsCache   = map[[2]int64]*paramsUL{}
+)
+
+// setupULCached returns a paramsUL for (u,l), reusing a previously computed
+// one when Setup is called again with the same parameters instead of
+// resigning all u elements of [0,u) from scratch.
+func setupULCached(u, l int64) (*paramsUL, error) {
+	key := [2]int64{u, l}
+
+	paramsCacheMu.Lock()
+	if p, ok := paramsCache[key]; ok {
+		paramsCacheMu.Unlock()
+		return p, nil
+	}
+	paramsCacheMu.Unlock()
+
+	p, err := SetupUL(u, l)
+	if err != nil {
+		return nil, err
+	}
+
+	paramsCacheMu.Lock()
+	paramsCache[key] = &p
+	paramsCacheMu.Unlock()
+	return &p, nil
+}
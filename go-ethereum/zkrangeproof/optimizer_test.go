@@ -0,0 +1,110 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkrangeproof
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// bruteMinU independently re-derives the smallest u with u^l >= w via
+// big.Int binary search, rather than reusing minUForL's float64 math.Pow
+// approach, so this test doesn't just check optimalUL against its own
+// helper.
+func bruteMinU(w, l int64) int64 {
+	lo, hi := int64(1), w
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if bigPowAtLeast(mid, l, w) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+// bigPowAtLeast reports whether u^l >= w, computed with big.Int so it
+// stays exact for w up to math.MaxInt64.
+func bigPowAtLeast(u, l, w int64) bool {
+	r := big.NewInt(1)
+	bu := big.NewInt(u)
+	bw := big.NewInt(w)
+	for i := int64(0); i < l; i++ {
+		r.Mul(r, bu)
+		if r.Cmp(bw) >= 0 {
+			return true
+		}
+	}
+	return r.Cmp(bw) >= 0
+}
+
+// TestOptimalULMinimizesCost checks, for a range of widths from 2^8 up to
+// math.MaxInt64 (the practical ceiling, since w is an int64), that
+// optimalUL returns the (u,l) minimizing DefaultCost subject to u^l >= w,
+// by independently recomputing the minimal u for every l in [1,
+// maxParamsULL] and confirming none beats optimalUL's answer.
+func TestOptimalULMinimizesCost(t *testing.T) {
+	widths := []int64{
+		1 << 8, 1 << 16, 1 << 24, 1 << 32, 1 << 40, 1 << 48, 1 << 56, math.MaxInt64,
+	}
+
+	for _, w := range widths {
+		u, l := optimalUL(w, DefaultCost)
+
+		if !bigPowAtLeast(u, l, w) {
+			t.Errorf("w=%d: optimalUL returned infeasible (u,l)=(%d,%d): u^l < w", w, u, l)
+			continue
+		}
+		if u > 2 && bigPowAtLeast(u-1, l, w) {
+			t.Errorf("w=%d: optimalUL returned (u,l)=(%d,%d) but u-1 is already feasible", w, u, l)
+		}
+
+		gotCost := DefaultCost(u, l)
+		const epsilon = 1e-9
+		for cl := int64(1); cl <= maxParamsULL; cl++ {
+			cu := bruteMinU(w, cl)
+			if cu < 2 {
+				cu = 2
+			}
+			if c := DefaultCost(cu, cl); c < gotCost-epsilon {
+				t.Errorf("w=%d: optimalUL picked (u,l)=(%d,%d) cost=%v, but (u,l)=(%d,%d) costs %v",
+					w, u, l, gotCost, cu, cl, c)
+			}
+		}
+	}
+}
+
+// TestOptimalULHandlesDegenerateWidths checks the w<2 fallback and the
+// nil-cost-function fallback to DefaultCost.
+func TestOptimalULHandlesDegenerateWidths(t *testing.T) {
+	if u, l := optimalUL(0, DefaultCost); u != 2 || l != 1 {
+		t.Errorf("optimalUL(0, ...) = (%d,%d), want (2,1)", u, l)
+	}
+	if u, l := optimalUL(1, DefaultCost); u != 2 || l != 1 {
+		t.Errorf("optimalUL(1, ...) = (%d,%d), want (2,1)", u, l)
+	}
+
+	withNil := func() (int64, int64) { return optimalUL(1<<32, nil) }
+	withDefault := func() (int64, int64) { return optimalUL(1<<32, DefaultCost) }
+	u1, l1 := withNil()
+	u2, l2 := withDefault()
+	if u1 != u2 || l1 != l2 {
+		t.Errorf("optimalUL with nil cost = (%d,%d), want same as DefaultCost (%d,%d)", u1, l1, u2, l2)
+	}
+}